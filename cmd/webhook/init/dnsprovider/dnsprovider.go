@@ -43,14 +43,9 @@ func Init(config configuration.Config) (provider.Provider, error) {
 	log.Info(createMsg)
 
 	eipConfig := soliddns.EfficientIPConfig{}
+	// Credentials are validated when loading them, as they may also come from *_FILE variables
 	if err := env.Parse(&eipConfig); err != nil {
 		return nil, fmt.Errorf("reading configuration failed: %v", err)
-	} else {
-		if eipConfig.Token == "" || eipConfig.Secret == "" {
-			if eipConfig.Username == "" || eipConfig.Password == "" {
-				return nil, fmt.Errorf("missing authentication credentials. Login/Password or access token/secret are required")
-			}
-		}
 	}
 
 	eipConfig.FQDNRegEx = config.RegexDomainFilter
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"encoding/json"
+	"expvar"
 	"fmt"
 	"net"
 	"net/http"
@@ -8,10 +10,27 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/trosvald/external-dns-soliddns-webhook/cmd/webhook/init/configuration"
+	"github.com/trosvald/external-dns-soliddns-webhook/internal/soliddns"
+	"github.com/trosvald/external-dns-soliddns-webhook/pkg/webhook"
 	"sigs.k8s.io/external-dns/provider"
-	"sigs.k8s.io/external-dns/provider/webhook/api"
 )
 
+// healthReporter is implemented by providers reporting the state of their backend connection
+type healthReporter interface {
+	Health() soliddns.HealthStatus
+}
+
+// cacheRefresher is implemented by providers caching zones and records between syncs
+type cacheRefresher interface {
+	RefreshCache()
+}
+
+// healthResponse is the body returned by the health endpoint
+type healthResponse struct {
+	Ready    bool                   `json:"ready"`
+	SolidDNS *soliddns.HealthStatus `json:"soliddns,omitempty"`
+}
+
 type WebhookServer struct {
 	Ready   bool
 	Channel chan struct{}
@@ -24,11 +43,42 @@ func NewServer() *WebhookServer {
 	}
 }
 
+// Start serves the webhook API. Handlers pass the request context to the provider,
+// so SOLIDserver calls are cancelled together with the external-dns request.
 func (ws *WebhookServer) Start(config configuration.Config, p provider.Provider) {
-	api.StartHTTPApi(p, ws.Channel, 0, 0, fmt.Sprintf("%s:%d", config.ServerHost, config.ServerPort))
+	hook := webhook.New(p)
+
+	m := http.NewServeMux()
+	m.HandleFunc("GET /{$}", hook.Negotiate)
+	m.HandleFunc("GET /records", hook.Records)
+	m.HandleFunc("POST /records", hook.ApplyChanges)
+	m.HandleFunc("POST /adjustendpoints", hook.AdjustEndpoints)
+
+	listenAddr := fmt.Sprintf("%s:%d", config.ServerHost, config.ServerPort)
+	s := &http.Server{
+		Addr:         listenAddr,
+		Handler:      m,
+		ReadTimeout:  config.ServerReadTimeout,
+		WriteTimeout: config.ServerWriteTimeout,
+	}
+
+	l, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if ws.Channel != nil {
+		ws.Channel <- struct{}{}
+	}
+
+	if err := s.Serve(l); err != nil {
+		log.Fatalf("[ERROR] Webhook listener stopped: %s", err)
+	}
 }
 
-func (ws *WebhookServer) StartHealth(config configuration.Config) {
+// StartHealth serves the health endpoint. The status code reflects readiness of the webhook,
+// the body additionally reports the SOLIDserver circuit breaker if the provider exposes it.
+func (ws *WebhookServer) StartHealth(config configuration.Config, p provider.Provider) {
 	go func() {
 		listenAddr := fmt.Sprintf("0.0.0.0:%d", config.HealthCheckPort)
 		m := http.NewServeMux()
@@ -38,11 +88,32 @@ func (ws *WebhookServer) StartHealth(config configuration.Config) {
 				ws.Ready = true
 			default:
 			}
+
+			body := healthResponse{Ready: ws.Ready}
+			if reporter, ok := p.(healthReporter); ok {
+				status := reporter.Health()
+				body.SolidDNS = &status
+			}
+
+			w.Header().Set("Content-Type", "application/json")
 			if ws.Ready {
 				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			if err := json.NewEncoder(w).Encode(body); err != nil {
+				log.Errorf("Failed to write health response: %v", err)
+			}
+		})
+		m.Handle("/debug/vars", expvar.Handler())
+		m.HandleFunc("POST /cache/refresh", func(w http.ResponseWriter, r *http.Request) {
+			refresher, ok := p.(cacheRefresher)
+			if !ok {
+				w.WriteHeader(http.StatusNotImplemented)
 				return
 			}
-			w.WriteHeader(http.StatusInternalServerError)
+			refresher.RefreshCache()
+			w.WriteHeader(http.StatusNoContent)
 		})
 		s := &http.Server{
 			Addr:    listenAddr,
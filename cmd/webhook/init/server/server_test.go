@@ -42,7 +42,7 @@ func TestMain(m *testing.M) {
 
 	go func() {
 		srv := NewServer()
-		srv.StartHealth(configuration.Init())
+		srv.StartHealth(configuration.Init(), mockProvider)
 		srv.Start(configuration.Init(), mockProvider)
 	}()
 
@@ -37,6 +37,6 @@ func main() {
 	}
 	srv := server.NewServer()
 
-	srv.StartHealth(config)
+	srv.StartHealth(config, provider)
 	srv.Start(config, provider)
 }
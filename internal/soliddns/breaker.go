@@ -0,0 +1,146 @@
+package soliddns
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned without calling SOLIDserver while the circuit breaker is open
+var ErrCircuitOpen = errors.New("SOLIDserver circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker guarding SOLIDserver calls
+type CircuitState string
+
+const (
+	// CircuitClosed lets all calls through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects all calls until the reset timeout expires
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe call through to decide whether to close again
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// HealthStatus reports the health of the SOLIDserver connection
+type HealthStatus struct {
+	Circuit             CircuitState     `json:"circuit"`
+	ConsecutiveFailures int              `json:"consecutiveFailures"`
+	LastError           string           `json:"lastError,omitempty"`
+	OpenUntil           *time.Time       `json:"openUntil,omitempty"`
+	ActiveEndpoint      string           `json:"activeEndpoint,omitempty"`
+	Endpoints           []EndpointStatus `json:"endpoints,omitempty"`
+}
+
+// Healthy reports whether calls currently reach SOLIDserver
+func (h HealthStatus) Healthy() bool {
+	return h.Circuit != CircuitOpen
+}
+
+// circuitBreaker fails calls fast after consecutive server failures.
+// After resetTimeout a single probe call is let through; its outcome closes or reopens the circuit.
+type circuitBreaker struct {
+	mu           sync.Mutex
+	threshold    int           // Consecutive failures opening the circuit (<= 0 disables the breaker)
+	resetTimeout time.Duration // Time the circuit stays open before probing
+	now          func() time.Time
+
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+}
+
+// newCircuitBreaker creates a closed circuit breaker
+func newCircuitBreaker(threshold int, resetTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+		now:          time.Now,
+		state:        CircuitClosed,
+	}
+}
+
+// allow returns ErrCircuitOpen if the call must not be issued
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Before(b.openedAt.Add(b.resetTimeout)) {
+			return ErrCircuitOpen
+		}
+		log.Infof("SOLIDserver circuit breaker half-open, probing the appliance")
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// success records a call that reached a healthy appliance
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != CircuitClosed {
+		log.Infof("SOLIDserver circuit breaker closed, appliance is reachable again")
+	}
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+	b.lastError = ""
+}
+
+// failure records a call that failed because the appliance is unreachable or unhealthy
+func (b *circuitBreaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if err != nil {
+		b.lastError = err.Error()
+	}
+
+	if b.threshold <= 0 {
+		return
+	}
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.threshold) {
+		log.Warnf("SOLIDserver circuit breaker open for %s after %d consecutive failures: %v", b.resetTimeout, b.failures, err)
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release gives up a call whose outcome says nothing about the appliance (e.g. cancelled by the caller)
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// status returns the current state of the breaker
+func (b *circuitBreaker) status() HealthStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := HealthStatus{
+		Circuit:             b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state == CircuitOpen {
+		openUntil := b.openedAt.Add(b.resetTimeout)
+		status.OpenUntil = &openUntil
+	}
+	return status
+}
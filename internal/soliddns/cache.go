@@ -0,0 +1,126 @@
+package soliddns
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// recordCache keeps listed zones and the records of each zone and scope between external-dns sync loops.
+// Entries expire after their TTL and are invalidated by applied changes. A generation
+// counter keeps listings that raced with an invalidation from being cached.
+type recordCache struct {
+	mu        sync.Mutex
+	zoneTTL   time.Duration // Lifetime of the zone list (0 disables zone caching)
+	recordTTL time.Duration // Lifetime of the records of a zone (0 disables record caching)
+	now       func() time.Time
+
+	generation uint64
+	zones      []*ZoneAuth
+	zonesAt    time.Time
+	records    map[string]map[string]cachedRecords // By zone name, then scope key
+}
+
+// cachedRecords are the records of a zone at the time they were listed
+type cachedRecords struct {
+	endpoints []*endpoint.Endpoint
+	listedAt  time.Time
+}
+
+// newRecordCache creates an empty cache
+func newRecordCache(zoneTTL, recordTTL time.Duration) *recordCache {
+	return &recordCache{
+		zoneTTL:   zoneTTL,
+		recordTTL: recordTTL,
+		now:       time.Now,
+		records:   make(map[string]map[string]cachedRecords),
+	}
+}
+
+// getZones returns the cached zone list if it has not expired.
+// The generation must be passed to setZones when storing a fresh listing.
+func (c *recordCache) getZones() (zones []*ZoneAuth, generation uint64, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.zoneTTL <= 0 || c.zones == nil || c.now().Sub(c.zonesAt) >= c.zoneTTL {
+		return nil, c.generation, false
+	}
+	return slices.Clone(c.zones), c.generation, true
+}
+
+// setZones caches a zone list unless the cache was invalidated since generation
+func (c *recordCache) setZones(zones []*ZoneAuth, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.zoneTTL <= 0 || generation != c.generation {
+		return
+	}
+	c.zones = slices.Clone(zones)
+	c.zonesAt = c.now()
+}
+
+// getRecords returns the cached records of a zone in a scope if they have not expired.
+// The generation must be passed to setRecords when storing a fresh listing.
+func (c *recordCache) getRecords(scope, zone string) (endpoints []*endpoint.Endpoint, generation uint64, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, found := c.records[zone][scope]
+	if c.recordTTL <= 0 || !found || c.now().Sub(cached.listedAt) >= c.recordTTL {
+		return nil, c.generation, false
+	}
+	// Callers filter the returned slice in place
+	return slices.Clone(cached.endpoints), c.generation, true
+}
+
+// setRecords caches the records of a zone in a scope unless the cache was invalidated since generation
+func (c *recordCache) setRecords(scope, zone string, endpoints []*endpoint.Endpoint, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.recordTTL <= 0 || generation != c.generation {
+		return
+	}
+	if c.records[zone] == nil {
+		c.records[zone] = make(map[string]cachedRecords)
+	}
+	c.records[zone][scope] = cachedRecords{endpoints: slices.Clone(endpoints), listedAt: c.now()}
+}
+
+// invalidateNames drops the records of every cached zone containing one of the names, in all scopes
+func (c *recordCache) invalidateNames(names []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for zone := range c.records {
+		for _, name := range names {
+			if inZone(name, zone) {
+				delete(c.records, zone)
+				break
+			}
+		}
+	}
+}
+
+// invalidate drops all cached zones and records
+func (c *recordCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.zones = nil
+	c.records = make(map[string]map[string]cachedRecords)
+}
+
+// inZone reports whether a record name belongs to a zone or one of its subdomains
+func inZone(name, zone string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
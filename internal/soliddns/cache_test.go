@@ -0,0 +1,89 @@
+package soliddns
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestRecordCacheExpiry(t *testing.T) {
+	now := time.Now()
+	cache := newRecordCache(10*time.Minute, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	_, generation, found := cache.getZones()
+	if found {
+		t.Fatalf("expected empty zone cache")
+	}
+	cache.setZones([]*ZoneAuth{{Name: "example.com"}}, generation)
+	_, generation, _ = cache.getRecords("", "example.com")
+	cache.setRecords("", "example.com", []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")}, generation)
+
+	now = now.Add(30 * time.Second)
+	if zones, _, found := cache.getZones(); !found || len(zones) != 1 {
+		t.Errorf("expected cached zones, got %v (found=%t)", zones, found)
+	}
+	if records, _, found := cache.getRecords("", "example.com"); !found || len(records) != 1 {
+		t.Errorf("expected cached records, got %v (found=%t)", records, found)
+	}
+
+	now = now.Add(time.Minute)
+	if _, _, found := cache.getZones(); !found {
+		t.Errorf("expected zones to outlive the record cache lifetime")
+	}
+	if _, _, found := cache.getRecords("", "example.com"); found {
+		t.Errorf("expected records to expire")
+	}
+}
+
+func TestRecordCacheInvalidation(t *testing.T) {
+	cache := newRecordCache(time.Hour, time.Hour)
+	for _, zone := range []string{"example.com", "sub.example.com", "example.org"} {
+		_, generation, _ := cache.getRecords("", zone)
+		cache.setRecords("", zone, []*endpoint.Endpoint{}, generation)
+	}
+	_, generation, _ := cache.getRecords("internal", "sub.example.com")
+	cache.setRecords("internal", "sub.example.com", []*endpoint.Endpoint{}, generation)
+
+	// A listing started before the invalidation must not be cached
+	_, stale, _ := cache.getRecords("", "example.net")
+
+	cache.invalidateNames([]string{"www.Sub.Example.com."})
+	cache.setRecords("", "example.net", []*endpoint.Endpoint{}, stale)
+
+	expected := map[string]bool{
+		"example.com":     false,
+		"sub.example.com": false,
+		"example.org":     true,
+		"example.net":     false,
+	}
+	for zone, cached := range expected {
+		if _, _, found := cache.getRecords("", zone); found != cached {
+			t.Errorf("zone %s: expected cached=%t, got %t", zone, cached, found)
+		}
+	}
+
+	if _, _, found := cache.getRecords("internal", "sub.example.com"); found {
+		t.Errorf("expected the records of all views to be dropped")
+	}
+
+	cache.invalidate()
+	if _, _, found := cache.getRecords("", "example.org"); found {
+		t.Errorf("expected forced refresh to drop all records")
+	}
+}
+
+func TestRecordCacheDisabled(t *testing.T) {
+	cache := newRecordCache(0, 0)
+	_, generation, _ := cache.getZones()
+	cache.setZones([]*ZoneAuth{{Name: "example.com"}}, generation)
+	cache.setRecords("", "example.com", []*endpoint.Endpoint{}, generation)
+
+	if _, _, found := cache.getZones(); found {
+		t.Errorf("expected zone caching to be disabled")
+	}
+	if _, _, found := cache.getRecords("", "example.com"); found {
+		t.Errorf("expected record caching to be disabled")
+	}
+}
@@ -2,8 +2,13 @@ package soliddns
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
 	log "github.com/sirupsen/logrus"
@@ -13,141 +18,383 @@ import (
 // EfficientIPAPI provides methods to interact with the EfficientIP SolidDNS API.
 // It implements the EfficientIPClient interface for DNS operations.
 type EfficientIPAPI struct {
-	client  *eip.APIClient  // Underlying EfficientIP API client
-	context context.Context // Context for API requests
-	dnsName string          // DNS smart name to operate on
-	dnsView string          // DNS view name (optional)
+	client         *eip.APIClient   // Underlying EfficientIP API client
+	credentials    *credentialStore // Authentication values, swapped on credential file reloads
+	endpoints      *endpointPool    // SOLIDserver nodes, calls go to the active one
+	requestTimeout time.Duration    // Deadline of a single API call (0 disables it)
+	retry          retryPolicy      // Backoff between attempts of failed calls
+	breaker        *circuitBreaker  // Fails calls fast while the appliance is down
+	readLimiter    *rateLimiter     // Rate limit of list calls
+	writeLimiter   *rateLimiter     // Rate limit of create, update and delete calls
+	dnsName        string           // DNS smart records are written to by default
+	dnsViews       []string         // DNS views records are written to by default (a single empty view if none is configured)
+	maxResults     int              // Page size for list requests (0 disables paging)
+	nameFilter     string           // Server-side record name condition derived from NameRegEx
+	index          *recordIndex     // Zones and rr_ids of listed records
 }
 
 // EfficientIPClient defines the interface for interacting with EfficientIP SolidDNS.
 // This interface allows for easier testing and alternative implementations.
 type EfficientIPClient interface {
 	// ZonesList retrieves all DNS zones matching the given configuration
-	ZonesList(config *EfficientIPConfig) ([]*ZoneAuth, error)
+	ZonesList(ctx context.Context, config *EfficientIPConfig) ([]*ZoneAuth, error)
 
 	// RecordAdd creates new DNS records based on the provided endpoint
-	RecordAdd(rr *endpoint.Endpoint) error
+	RecordAdd(ctx context.Context, rr *endpoint.Endpoint) error
 
 	// RecordDelete removes DNS records specified by the endpoint
-	RecordDelete(rr *endpoint.Endpoint) error
+	RecordDelete(ctx context.Context, rr *endpoint.Endpoint) error
+
+	// RecordUpdate changes existing DNS records in place from the current to the desired endpoint
+	RecordUpdate(ctx context.Context, current, desired *endpoint.Endpoint) error
 
 	// RecordList retrieves all DNS records for a specific zone
-	RecordList(Zone ZoneAuth) (endpoints []*endpoint.Endpoint, _ error)
+	RecordList(ctx context.Context, Zone ZoneAuth) (endpoints []*endpoint.Endpoint, _ error)
+
+	// Health reports the state of the connection to SOLIDserver
+	Health() HealthStatus
 }
 
 // NewEfficientIPAPI creates a new instance of the EfficientIP API client.
 // Parameters:
-//   - ctx: Context for API requests (should include authentication)
 //   - config: EfficientIP API configuration
 //   - eipConfig: Provider-specific configuration
+//   - credentials: Store of the credentials used to authenticate calls
+//   - endpoints: SOLIDserver nodes to send calls to
 //
 // Returns:
 //   - Initialized EfficientIPAPI instance
-func NewEfficientIPAPI(ctx context.Context, config *eip.Configuration, eipConfig *EfficientIPConfig) EfficientIPAPI {
+func NewEfficientIPAPI(config *eip.Configuration, eipConfig *EfficientIPConfig, credentials *credentialStore, endpoints *endpointPool) EfficientIPAPI {
 	return EfficientIPAPI{
-		client:  eip.NewAPIClient(config),
-		context: ctx,
-		dnsName: eipConfig.DnsSmart,
-		dnsView: eipConfig.DnsView,
+		client:         eip.NewAPIClient(config),
+		credentials:    credentials,
+		endpoints:      endpoints,
+		requestTimeout: eipConfig.RequestTimeout,
+		retry: retryPolicy{
+			maxAttempts: eipConfig.RetryMaxAttempts,
+			baseDelay:   eipConfig.RetryBaseDelay,
+			maxDelay:    eipConfig.RetryMaxDelay,
+		},
+		breaker:      newCircuitBreaker(eipConfig.CircuitFailureThreshold, eipConfig.CircuitResetTimeout),
+		readLimiter:  newRateLimiter(eipConfig.ReadRateLimit, eipConfig.ReadRateBurst),
+		writeLimiter: newRateLimiter(eipConfig.WriteRateLimit, eipConfig.WriteRateBurst),
+		dnsName:      eipConfig.DnsSmart,
+		dnsViews:     eipConfig.views(),
+		maxResults:   eipConfig.MaxResults,
+		nameFilter:   buildRecordNameFilter(eipConfig.NameRegEx),
+		index:        newRecordIndex(),
 	}
 }
 
+// requestContext derives the context of a single API call from the caller context.
+// Authentication and the server variables of the node are layered on top and the per-call timeout applied,
+// so cancelling the caller (e.g. the webhook HTTP request) aborts the call.
+// Parameters:
+//   - ctx: Caller context
+//   - node: SOLIDserver node to send the call to
+//
+// Returns:
+//   - Context to pass to the API call
+//   - Cancel function that must be called once the call completes
+func (e *EfficientIPAPI) requestContext(ctx context.Context, node apiEndpoint) (context.Context, context.CancelFunc) {
+	if creds := e.credentials.load(); creds.tokenAuth != nil {
+		ctx = context.WithValue(ctx, eip.ContextEipApiTokenAuth, *creds.tokenAuth)
+	} else {
+		ctx = context.WithValue(ctx, eip.ContextBasicAuth, *creds.basicAuth)
+	}
+	ctx = context.WithValue(ctx, eip.ContextServerVariables, map[string]string{
+		"host": node.host,
+		"port": node.port,
+	})
+
+	if e.requestTimeout > 0 {
+		return context.WithTimeout(ctx, e.requestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Health reports the state of the circuit breaker guarding SOLIDserver calls and of the nodes.
+// Returns:
+//   - Current health status
+func (e *EfficientIPAPI) Health() HealthStatus {
+	status := e.breaker.status()
+	status.ActiveEndpoint, status.Endpoints = e.endpoints.statuses()
+	return status
+}
+
 // ZonesList retrieves all DNS zones matching the configuration.
-// It constructs a query based on the DNS smart name and optional view,
-// then converts the API response to our internal ZoneAuth format.
+// It constructs a query for each smart and view records may be written to,
+// pages through the results and converts them to our internal ZoneAuth format.
+// Zones present in several views are returned once per view, tagged with the view they live in;
+// without a configured view the zones of every view of a smart are listed.
 // Parameters:
-//   - config: Configuration containing DNS smart name and view
+//   - ctx: Caller context
+//   - config: Configuration containing DNS smart names and views
 //
 // Returns:
 //   - Slice of ZoneAuth pointers representing matching zones
 //   - Error if API request fails or response indicates failure
-func (e *EfficientIPAPI) ZonesList(config *EfficientIPConfig) ([]*ZoneAuth, error) {
-	whereClause := buildZoneWhereClause(config)
-	log.Debugf("Listing Zones with filter: %s", whereClause)
+func (e *EfficientIPAPI) ZonesList(ctx context.Context, config *EfficientIPConfig) ([]*ZoneAuth, error) {
+	var result []*ZoneAuth
+	for _, scope := range config.listedScopes() {
+		whereClause := buildZoneWhereClause(config, scope)
+		log.Debugf("Listing Zones with filter: %s", whereClause)
+
+		for offset := 0; ; offset += e.maxResults {
+			page, err := e.listZonePage(ctx, whereClause, offset)
+			if err != nil {
+				return nil, err
+			}
+			for _, zone := range convertZoneData(page) {
+				zone.Smart = scope.smart
+				result = append(result, zone)
+			}
+
+			if e.maxResults <= 0 || len(page) < e.maxResults {
+				break
+			}
+		}
+	}
 
-	zones, resp, err := e.client.DnsAPI.DnsZoneList(e.context).Where(whereClause).Execute()
+	return result, nil
+}
 
+// listZonePage retrieves a single page of DNS zones.
+// Parameters:
+//   - ctx: Caller context
+//   - whereClause: Zone filter
+//   - offset: Index of the first zone of the page
+//
+// Returns:
+//   - Zones of the page
+//   - Error if API request fails or response indicates failure
+func (e *EfficientIPAPI) listZonePage(ctx context.Context, whereClause string, offset int) ([]eip.DataInnerDnsZoneData, error) {
+	var zones *eip.DnsZoneData
+	err := e.do(ctx, callRead, func(ctx context.Context) (resp *http.Response, err error) {
+		req := e.client.DnsAPI.DnsZoneList(ctx).
+			Where(whereClause).
+			Orderby("zone_name,zone_id")
+		if e.maxResults > 0 {
+			req = req.Limit(int32(e.maxResults)).Offset(int32(offset))
+		}
+		zones, resp, err = req.Execute()
+		return resp, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API returned status: %d", resp.StatusCode)
-	}
-
 	if !zones.HasSuccess() || !zones.GetSuccess() {
 		return nil, fmt.Errorf("API response indicated failure")
 	}
 
-	return convertZoneData(zones.GetData()), nil
+	return zones.GetData(), nil
 }
 
 // RecordList retrieves all DNS records for a specific zone.
-// It handles different record types (A, TXT, CNAME) and converts them
-// to external-dns endpoint format.
+// It pages through the zone using MaxResults as the page size, handles different
+// record types (A, AAAA, MX, SRV, TXT, CNAME) and converts them to external-dns endpoint format.
 // Parameters:
+//   - ctx: Caller context
 //   - zone: The zone to list records for
 //
 // Returns:
 //   - Slice of endpoints representing DNS records
 //   - Error if API request fails or response indicates failure
-func (e *EfficientIPAPI) RecordList(zone ZoneAuth) ([]*endpoint.Endpoint, error) {
-	log.Debugf("Listing records for zone ID: %s (%s)", zone.ID, zone.Name)
+func (e *EfficientIPAPI) RecordList(ctx context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+	log.Debugf("Listing records for zone ID: %s (%s%s)", zone.ID, zone.Name, e.inScope(zone.scope()))
 
-	records, resp, err := e.client.DnsAPI.DnsRrList(e.context).
-		Where("zone_id=" + zone.ID).
-		Orderby("rr_full_name").
-		Execute()
+	e.index.resetZone(zone.scope(), zone.Name)
 
-	if err != nil {
-		return nil, fmt.Errorf("API request failed for zone %s: %w", zone.Name, err)
+	converter := newRecordConverter()
+	for offset := 0; ; offset += e.maxResults {
+		page, err := e.listRecordPage(ctx, zone, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debugf("Fetched %d records at offset %d for zone %s", len(page), offset, zone.Name)
+		converter.add(page)
+		for _, rr := range page {
+			id, err := parseRecordID(rr.GetRrId())
+			if err != nil {
+				log.Warnf("Ignoring %v of %s record %s, it is changed by its attributes instead", err, rr.GetRrType(), rr.GetRrFullName())
+			}
+			e.index.setRecord(zone.scope(), zone.Name, rr.GetRrFullName(), rr.GetRrType(), recordTarget(rr), id)
+		}
+
+		if e.maxResults <= 0 || len(page) < e.maxResults {
+			break
+		}
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API returned status %d for zone %s", resp.StatusCode, zone.Name)
+	// Echo the scope so endpoints selecting a view or smart compare equal to their records
+	endpoints := converter.result()
+	for _, ep := range endpoints {
+		if zone.Smart != "" {
+			ep.WithProviderSpecific(providerSpecificEfficientipSmart, zone.Smart)
+		}
+		if zone.View != "" {
+			ep.WithProviderSpecific(providerSpecificEfficientipView, zone.View)
+		}
+	}
+	return endpoints, nil
+}
+
+// listRecordPage retrieves a single page of DNS records of a zone.
+// Parameters:
+//   - ctx: Caller context
+//   - zone: The zone to list records for
+//   - offset: Index of the first record of the page
+//
+// Returns:
+//   - Records of the page
+//   - Error if API request fails or response indicates failure
+func (e *EfficientIPAPI) listRecordPage(ctx context.Context, zone ZoneAuth, offset int) ([]eip.DataInnerDnsRrData, error) {
+	var records *eip.DnsRrData
+	err := e.do(ctx, callRead, func(ctx context.Context) (resp *http.Response, err error) {
+		req := e.client.DnsAPI.DnsRrList(ctx).
+			Where(buildRecordWhereClause(zone, e.nameFilter)).
+			Orderby("rr_full_name,rr_id")
+		if e.maxResults > 0 {
+			req = req.Limit(int32(e.maxResults)).Offset(int32(offset))
+		}
+		records, resp, err = req.Execute()
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("API request failed for zone %s: %w", zone.Name, err)
 	}
 
 	if !records.HasSuccess() || !records.GetSuccess() {
 		return nil, fmt.Errorf("API response indicated failure for zone %s", zone.Name)
 	}
 
-	return convertRecordsToEndpoints(records.GetData())
+	return records.GetData(), nil
 }
 
 // RecordAdd creates new DNS records based on the provided endpoint.
-// It handles multiple targets by creating individual records for each target.
+// It handles multiple targets by creating individual records for each target in every scope of the endpoint.
 // Parameters:
+//   - ctx: Caller context
 //   - ep: Endpoint containing record details (type, name, targets, TTL)
 //
 // Returns:
 //   - Error if no targets provided or any record creation fails
-func (e *EfficientIPAPI) RecordAdd(ep *endpoint.Endpoint) error {
+func (e *EfficientIPAPI) RecordAdd(ctx context.Context, ep *endpoint.Endpoint) error {
 	if len(ep.Targets) == 0 {
 		return fmt.Errorf("no targets provided for record %s", ep.DNSName)
 	}
 
-	for _, target := range ep.Targets {
-		if err := e.createSingleRecord(ep, target); err != nil {
-			return err
+	for _, scope := range e.recordScopes(ep) {
+		for _, target := range ep.Targets {
+			if err := e.createSingleRecord(ctx, scope, ep, target); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
 // RecordDelete removes DNS records specified by the endpoint.
-// It handles multiple targets by deleting individual records for each target.
+// It handles multiple targets by deleting individual records for each target in every scope of the endpoint.
 // Parameters:
+//   - ctx: Caller context
 //   - ep: Endpoint containing record details to delete
 //
 // Returns:
 //   - Error if no targets provided or any record deletion fails
-func (e *EfficientIPAPI) RecordDelete(ep *endpoint.Endpoint) error {
+func (e *EfficientIPAPI) RecordDelete(ctx context.Context, ep *endpoint.Endpoint) error {
 	if len(ep.Targets) == 0 {
 		return fmt.Errorf("no targets provided for record %s", ep.DNSName)
 	}
 
-	for _, target := range ep.Targets {
-		if err := e.deleteSingleRecord(ep, target); err != nil {
+	for _, scope := range e.recordScopes(ep) {
+		for _, target := range ep.Targets {
+			if err := e.deleteSingleRecord(ctx, scope, ep, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordUpdate changes existing DNS records of the same name and type in place in every scope.
+// Targets present in both endpoints only get their TTL and ownership updated, removed targets are
+// rewritten to added ones, and only surplus targets are deleted or created.
+// If the endpoint moves to another view or smart, its records are created in the new
+// scopes before they are deleted from the scopes it left.
+// Parameters:
+//   - ctx: Caller context
+//   - current: Endpoint as currently present in SOLIDserver
+//   - desired: Desired endpoint with the same name and type
+//
+// Returns:
+//   - Error if any record update, creation or deletion fails
+func (e *EfficientIPAPI) RecordUpdate(ctx context.Context, current, desired *endpoint.Endpoint) error {
+	currentScopes, desiredScopes := e.recordScopes(current), e.recordScopes(desired)
+
+	for _, scope := range desiredScopes {
+		if slices.Contains(currentScopes, scope) {
+			if err := e.updateRecordsInScope(ctx, scope, current, desired); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, target := range desired.Targets {
+			if err := e.createSingleRecord(ctx, scope, desired, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, scope := range currentScopes {
+		if slices.Contains(desiredScopes, scope) {
+			continue
+		}
+		for _, target := range current.Targets {
+			if err := e.deleteSingleRecord(ctx, scope, current, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateRecordsInScope changes the DNS records of an endpoint in place in a single scope.
+// Parameters:
+//   - ctx: Caller context
+//   - scope: DNS smart and view of the records
+//   - current: Endpoint as currently present in SOLIDserver
+//   - desired: Desired endpoint with the same name and type
+//
+// Returns:
+//   - Error if any record update, creation or deletion fails
+func (e *EfficientIPAPI) updateRecordsInScope(ctx context.Context, scope recordScope, current, desired *endpoint.Endpoint) error {
+	common, removed, added := diffTargets(current.RecordType, current.Targets, desired.Targets)
+
+	if current.RecordTTL != desired.RecordTTL || ownershipChanged(current.Labels, desired.Labels) {
+		for _, target := range common {
+			if err := e.updateSingleRecord(ctx, scope, current, target, desired, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	for len(removed) > 0 && len(added) > 0 {
+		if err := e.updateSingleRecord(ctx, scope, current, removed[0], desired, added[0]); err != nil {
+			return err
+		}
+		removed, added = removed[1:], added[1:]
+	}
+
+	for _, target := range added {
+		if err := e.createSingleRecord(ctx, scope, desired, target); err != nil {
+			return err
+		}
+	}
+	for _, target := range removed {
+		if err := e.deleteSingleRecord(ctx, scope, current, target); err != nil {
 			return err
 		}
 	}
@@ -155,76 +402,417 @@ func (e *EfficientIPAPI) RecordDelete(ep *endpoint.Endpoint) error {
 }
 
 // createSingleRecord handles creation of a single DNS record.
-// This is an internal helper method called by RecordAdd for each target.
+// This is an internal helper method called by RecordAdd for each scope and target.
 // Parameters:
+//   - ctx: Caller context
+//   - scope: DNS smart and view to create the record in
 //   - ep: Endpoint containing record details
 //   - target: Specific target value for this record
 //
 // Returns:
 //   - Error if API request fails or response indicates failure
-func (e *EfficientIPAPI) createSingleRecord(ep *endpoint.Endpoint, target string) error {
-	log.Debugf("Creating %s record: %s -> %s (TTL: %d)", ep.RecordType, ep.DNSName, target, ep.RecordTTL)
+func (e *EfficientIPAPI) createSingleRecord(ctx context.Context, scope recordScope, ep *endpoint.Endpoint, target string) error {
+	target = normalizeTarget(ep.RecordType, target)
+	log.Debugf("Creating %s record: %s -> %s (TTL: %d)%s", ep.RecordType, ep.DNSName, target, ep.RecordTTL, e.inScope(scope))
+
+	values, err := recordValues(ep.RecordType, target)
+	if err != nil {
+		return fmt.Errorf("invalid %s record %s: %w", ep.RecordType, ep.DNSName, err)
+	}
 
 	ttl := int32(ep.RecordTTL)
 	input := eip.DnsRrAddInput{
-		ServerName: &e.dnsName,
-		ViewName:   &e.dnsView,
+		ServerName: &scope.smart,
+		ViewName:   &scope.view,
 		RrName:     &ep.DNSName,
 		RrType:     &ep.RecordType,
 		RrTtl:      &ttl,
-		RrValue1:   &target,
 	}
-
-	_, resp, err := e.client.DnsAPI.DnsRrAdd(e.context).DnsRrAddInput(input).Execute()
+	ptrs := recordValuePointers(values)
+	input.RrValue1, input.RrValue2, input.RrValue3, input.RrValue4 = ptrs[0], ptrs[1], ptrs[2], ptrs[3]
+	// Keep ownership on the record itself, visible as class parameters in SOLIDserver
+	input.RrClassParameters = ownershipClassParameters(ep.Labels)
+
+	err = e.do(ctx, callCreate, func(ctx context.Context) (*http.Response, error) {
+		_, resp, err := e.client.DnsAPI.DnsRrAdd(ctx).DnsRrAddInput(input).Execute()
+		return resp, err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create %s record %s: %w", ep.RecordType, ep.DNSName, err)
-	}
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API returned status %s when creating record %s", resp.StatusCode, ep.DNSName)
+		if !errors.Is(err, ErrConflict) {
+			return fmt.Errorf("failed to create %s record %s: %w", ep.RecordType, ep.DNSName, err)
+		}
+		// Only an identical record makes the creation a no-op, conflicting ones are errors
+		id, lookupErr := e.lookupRecordID(ctx, scope, ep, target)
+		if lookupErr != nil {
+			return fmt.Errorf("failed to create %s record %s: %w", ep.RecordType, ep.DNSName, err)
+		}
+		log.Debugf("%s record %s -> %s already exists (rr_id %d)%s, nothing to create", ep.RecordType, ep.DNSName, target, id, e.inScope(scope))
+		return nil
 	}
-	log.Infof("Successfully created %s record: %s -> %s (TTL: %d)", ep.RecordType, ep.DNSName, target)
+	log.Infof("Successfully created %s record: %s -> %s (TTL: %d)%s", ep.RecordType, ep.DNSName, target, ep.RecordTTL, e.inScope(scope))
 	return nil
 }
 
 // deleteSingleRecord handles deletion of a single DNS record.
-// This is an internal helper method called by RecordDelete for each target.
+// This is an internal helper method called by RecordDelete for each scope and target.
+// Records whose rr_id is known from listing are deleted by id, others by attributes.
 // Parameters:
+//   - ctx: Caller context
+//   - scope: DNS smart and view to delete the record from
 //   - ep: Endpoint containing record details to delete
 //   - target: Specific target value for this record
 //
 // Returns:
 //   - Error if API request fails or response indicates failure
-func (e *EfficientIPAPI) deleteSingleRecord(ep *endpoint.Endpoint, target string) error {
-	log.Debugf("Deleting %s record: %s -> %s", ep.RecordType, ep.DNSName, target)
-
-	_, resp, err := e.client.DnsAPI.DnsRrDelete(e.context).
-		RrName(ep.DNSName).
-		RrType(ep.RecordType).
-		RrValue1(target).
-		Execute()
+func (e *EfficientIPAPI) deleteSingleRecord(ctx context.Context, scope recordScope, ep *endpoint.Endpoint, target string) error {
+	target = normalizeTarget(ep.RecordType, target)
+	log.Debugf("Deleting %s record: %s -> %s%s", ep.RecordType, ep.DNSName, target, e.inScope(scope))
+
+	ids := e.index.recordIDs(scope, ep.DNSName, ep.RecordType, target)
+	if len(ids) == 0 {
+		if err := e.deleteRecordByAttributes(ctx, scope, ep, target); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if err := e.deleteRecordByID(ctx, ep, id); err != nil {
+			return err
+		}
+	}
+	e.index.forget(scope, ep.DNSName, ep.RecordType, target)
+
+	log.Infof("Successfully deleted %s record: %s -> %s%s", ep.RecordType, ep.DNSName, target, e.inScope(scope))
+	return nil
+}
+
+// deleteRecordByID deletes the DNS record with the given SOLIDserver rr_id.
+// Parameters:
+//   - ctx: Caller context
+//   - ep: Endpoint containing record details (used in error messages)
+//   - id: rr_id of the record
+//
+// Returns:
+//   - Error if API request fails or response indicates failure
+func (e *EfficientIPAPI) deleteRecordByID(ctx context.Context, ep *endpoint.Endpoint, id int32) error {
+	log.Debugf("Deleting %s record %s by rr_id %d", ep.RecordType, ep.DNSName, id)
+
+	err := e.do(ctx, callWrite, func(ctx context.Context) (*http.Response, error) {
+		_, resp, err := e.client.DnsAPI.DnsRrDelete(ctx).RrId(id).Execute()
+		return resp, err
+	})
+	if isRecordNotFound(err) {
+		log.Debugf("%s record %s (rr_id %d) is already deleted", ep.RecordType, ep.DNSName, id)
+		return nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		log.Warnf("Failed to delete %s record %s (rr_id %d), SOLIDserver reports a missing object other than the record: %v", ep.RecordType, ep.DNSName, id, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s record %s (rr_id %d): %w", ep.RecordType, ep.DNSName, id, err)
+	}
+	return nil
+}
+
+// deleteRecordByAttributes deletes a DNS record matching its name, type and values.
+// Used when the rr_id of the record is unknown.
+// Parameters:
+//   - ctx: Caller context
+//   - scope: DNS smart and view to delete the record from
+//   - ep: Endpoint containing record details to delete
+//   - target: Normalized target value for this record
+//
+// Returns:
+//   - Error if API request fails or response indicates failure
+func (e *EfficientIPAPI) deleteRecordByAttributes(ctx context.Context, scope recordScope, ep *endpoint.Endpoint, target string) error {
+	values, err := recordValues(ep.RecordType, target)
+	if err != nil {
+		return fmt.Errorf("invalid %s record %s: %w", ep.RecordType, ep.DNSName, err)
+	}
+
+	zone, zoneFound := e.index.zone(scope, ep.DNSName, ep.RecordType)
+	err = e.do(ctx, callWrite, func(ctx context.Context) (*http.Response, error) {
+		// Scope the deletion to our smart, view and listed zone so other copies are left untouched
+		req := e.client.DnsAPI.DnsRrDelete(ctx).
+			ServerName(scope.smart).
+			RrName(ep.DNSName).
+			RrType(ep.RecordType)
+		if scope.view != "" {
+			req = req.ViewName(scope.view)
+		}
+		if zoneFound {
+			req = req.ZoneName(zone)
+		}
+
+		_, resp, err := setRecordDeleteValues(req, values).Execute()
+		return resp, err
+	})
+	if isRecordNotFound(err) {
+		log.Debugf("%s record %s -> %s is already deleted%s", ep.RecordType, ep.DNSName, target, e.inScope(scope))
+		return nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		log.Warnf("Failed to delete %s record %s%s, SOLIDserver reports a missing object other than the record, check the configured smart and view: %v", ep.RecordType, ep.DNSName, e.inScope(scope), err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete %s record %s: %w", ep.RecordType, ep.DNSName, err)
 	}
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API returned status %d when deleting record %s", resp.StatusCode, ep.DNSName)
+	return nil
+}
+
+// recordValuePointers maps ordered rr_value fields to the optional rr_value1..rr_value4 inputs.
+// Parameters:
+//   - values: Ordered rr_value fields as returned by recordValues
+//
+// Returns:
+//   - Pointers to the values, nil for unused fields
+func recordValuePointers(values []string) [4]*string {
+	var ptrs [4]*string
+	for i := range values {
+		if i < len(ptrs) {
+			ptrs[i] = &values[i]
+		}
 	}
+	return ptrs
+}
 
-	log.Infof("Successfully deleted %s record: %s -> %s", ep.RecordType, ep.DNSName, target)
+// updateSingleRecord handles the in-place update of a single DNS record.
+// The record is identified by its current target and rewritten with the new target, TTL and ownership.
+// Parameters:
+//   - ctx: Caller context
+//   - scope: DNS smart and view of the record
+//   - current: Endpoint containing the current record details
+//   - currentTarget: Current target value of the record
+//   - desired: Endpoint containing the desired record details
+//   - desiredTarget: Desired target value of the record
+//
+// Returns:
+//   - Error if the record cannot be found or the API request fails
+func (e *EfficientIPAPI) updateSingleRecord(ctx context.Context, scope recordScope, current *endpoint.Endpoint, currentTarget string, desired *endpoint.Endpoint, desiredTarget string) error {
+	desiredTarget = normalizeTarget(desired.RecordType, desiredTarget)
+	log.Debugf("Updating %s record: %s -> %s to %s (TTL: %d)%s", desired.RecordType, desired.DNSName, currentTarget, desiredTarget, desired.RecordTTL, e.inScope(scope))
+
+	values, err := recordValues(desired.RecordType, desiredTarget)
+	if err != nil {
+		return fmt.Errorf("invalid %s record %s: %w", desired.RecordType, desired.DNSName, err)
+	}
+
+	var id int32
+	if ids := e.index.recordIDs(scope, current.DNSName, current.RecordType, currentTarget); len(ids) > 0 {
+		id = ids[0]
+	} else if id, err = e.lookupRecordID(ctx, scope, current, currentTarget); err != nil {
+		return err
+	}
+
+	ttl := int32(desired.RecordTTL)
+	input := eip.DnsRrEditInput{
+		RrId:  &id,
+		RrTtl: &ttl,
+	}
+	ptrs := recordValuePointers(values)
+	input.RrValue1, input.RrValue2, input.RrValue3, input.RrValue4 = ptrs[0], ptrs[1], ptrs[2], ptrs[3]
+	// Owner or resource changes are carried by the update as well
+	input.RrClassParameters = ownershipClassParameters(desired.Labels)
+	input.ClassParametersToDelete = staleOwnershipClassParameters(current.Labels, desired.Labels)
+
+	err = e.do(ctx, callWrite, func(ctx context.Context) (*http.Response, error) {
+		_, resp, err := e.client.DnsAPI.DnsRrEdit(ctx).DnsRrEditInput(input).Execute()
+		return resp, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update %s record %s: %w", desired.RecordType, desired.DNSName, err)
+	}
+
+	if zone, found := e.index.zone(scope, current.DNSName, current.RecordType); found {
+		e.index.forget(scope, current.DNSName, current.RecordType, currentTarget)
+		e.index.setRecord(scope, zone, desired.DNSName, desired.RecordType, desiredTarget, id)
+	}
+	log.Infof("Successfully updated %s record: %s -> %s (TTL: %d)%s", desired.RecordType, desired.DNSName, desiredTarget, desired.RecordTTL, e.inScope(scope))
 	return nil
 }
 
+// lookupRecordID finds the SOLIDserver rr_id of a single DNS record in a scope.
+// The listing is only trusted to narrow the candidates: the rr_id is taken from a
+// listed record whose name, type and values match exactly.
+// Parameters:
+//   - ctx: Caller context
+//   - scope: DNS smart and view of the record
+//   - ep: Endpoint containing record details
+//   - target: Specific target value of the record
+//
+// Returns:
+//   - The rr_id of the matching record
+//   - Error if the record cannot be queried safely, the API request fails, no record matches or its rr_id is invalid
+func (e *EfficientIPAPI) lookupRecordID(ctx context.Context, scope recordScope, ep *endpoint.Endpoint, target string) (int32, error) {
+	target = normalizeTarget(ep.RecordType, target)
+	values, err := recordValues(ep.RecordType, target)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s record %s: %w", ep.RecordType, ep.DNSName, err)
+	}
+
+	fields := [][2]string{{"rr_full_name", ep.DNSName}, {"rr_type", ep.RecordType}}
+	for i, value := range values {
+		fields = append(fields, [2]string{fmt.Sprintf("rr_value%d", i+1), value})
+	}
+	if zone, found := e.index.zone(scope, ep.DNSName, ep.RecordType); found {
+		fields = append(fields, [2]string{"zone_name", zone})
+	}
+	conditions := []string{buildScopeClause(scope.smart, scope.view)}
+	for _, field := range fields {
+		condition, ok := whereEquals(field[0], field[1])
+		if !ok {
+			return 0, fmt.Errorf("cannot look up %s record %s: %s holds a quote or backslash", ep.RecordType, ep.DNSName, field[0])
+		}
+		conditions = append(conditions, condition)
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var records *eip.DnsRrData
+	err = e.do(ctx, callRead, func(ctx context.Context) (resp *http.Response, err error) {
+		records, resp, err = e.client.DnsAPI.DnsRrList(ctx).Where(where).Execute()
+		return resp, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up %s record %s: %w", ep.RecordType, ep.DNSName, err)
+	}
+
+	key := targetKey(ep.DNSName, ep.RecordType, target)
+	for _, rr := range records.GetData() {
+		if !records.GetSuccess() || targetKey(rr.GetRrFullName(), rr.GetRrType(), recordTarget(rr)) != key {
+			continue
+		}
+		id, err := parseRecordID(rr.GetRrId())
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up %s record %s: %w", ep.RecordType, ep.DNSName, err)
+		}
+		return id, nil
+	}
+	return 0, fmt.Errorf("%s record %s -> %s not found%s", ep.RecordType, ep.DNSName, target, e.inScope(scope))
+}
+
+// setRecordDeleteValues constrains a record deletion request to the given rr_value fields.
+// Parameters:
+//   - req: Record deletion request
+//   - values: Ordered rr_value fields as returned by recordValues
+//
+// Returns:
+//   - Deletion request matching on all provided values
+func setRecordDeleteValues(req eip.ApiDnsRrDeleteRequest, values []string) eip.ApiDnsRrDeleteRequest {
+	for i, value := range values {
+		switch i {
+		case 0:
+			req = req.RrValue1(value)
+		case 1:
+			req = req.RrValue2(value)
+		case 2:
+			req = req.RrValue3(value)
+		case 3:
+			req = req.RrValue4(value)
+		}
+	}
+	return req
+}
+
 // buildZoneWhereClause constructs the filter for zone listing.
-// Combines the DNS smart name with optional view name if specified.
+// Combines the DNS smart name with optional view name and FQDN regex if specified.
 // Parameters:
-//   - config: Configuration containing DNS smart name and view
+//   - config: Configuration containing the FQDN regex
+//   - scope: DNS smart and view (may be empty) to list zones of
 //
 // Returns:
 //   - SQL-like WHERE clause string for API filtering
-func buildZoneWhereClause(config *EfficientIPConfig) string {
-	where := fmt.Sprintf("server_name='%s'", config.DnsSmart)
-	if config.DnsView != "" {
-		where += fmt.Sprintf(" AND view = '%s'", config.DnsView)
+func buildZoneWhereClause(config *EfficientIPConfig, scope recordScope) string {
+	where := buildScopeClause(scope.smart, scope.view)
+	if clause, ok := regexToLikeClause("zone_name", config.FQDNRegEx); ok {
+		where += " AND " + clause
+	}
+	return where
+}
+
+// buildScopeClause constructs the filter restricting a listing to a DNS smart and optional view.
+// Parameters:
+//   - smart: DNS smart name
+//   - view: DNS view name (may be empty)
+//
+// Returns:
+//   - SQL-like WHERE clause string for API filtering
+func buildScopeClause(smart, view string) string {
+	where := fmt.Sprintf("server_name='%s'", smart)
+	if view != "" {
+		where += fmt.Sprintf(" AND view_name='%s'", view)
+	}
+	return where
+}
+
+// recordScopes returns the smart and views an endpoint is written to.
+// The view and smart provider-specific properties override the configured defaults.
+// Parameters:
+//   - ep: Endpoint to write
+//
+// Returns:
+//   - Scopes of the endpoint records
+func (e *EfficientIPAPI) recordScopes(ep *endpoint.Endpoint) []recordScope {
+	smart := e.dnsName
+	if value, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipSmart); found && value != "" {
+		smart = value
+	}
+	views := e.dnsViews
+	if value, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipView); found && value != "" {
+		views = []string{value}
+	}
+
+	scopes := make([]recordScope, 0, len(views))
+	for _, view := range views {
+		scopes = append(scopes, recordScope{smart: smart, view: view})
+	}
+	return scopes
+}
+
+// inScope describes the scope of a record in log and error messages.
+// Parameters:
+//   - scope: DNS smart and view of the record
+//
+// Returns:
+//   - " in view <view>" if a view is set, followed by " of smart <smart>" for other than the default smart
+func (e *EfficientIPAPI) inScope(scope recordScope) string {
+	var description string
+	if scope.view != "" {
+		description = fmt.Sprintf(" in view %s", scope.view)
+	}
+	if scope.smart != e.dnsName {
+		description += fmt.Sprintf(" of smart %s", scope.smart)
+	}
+	return description
+}
+
+// buildRecordNameFilter derives the server-side record name condition from the name regex.
+// Parameters:
+//   - nameRegEx: Record name regular expression (may be empty)
+//
+// Returns:
+//   - WHERE condition on rr_full_name, or an empty string if the regex cannot be pushed down
+func buildRecordNameFilter(nameRegEx string) string {
+	clause, ok := regexToLikeClause("rr_full_name", nameRegEx)
+	if !ok {
+		if nameRegEx != "" {
+			log.Debugf("Name filter '%s' is applied client-side only", nameRegEx)
+		}
+		return ""
+	}
+	return clause
+}
+
+// buildRecordWhereClause constructs the filter for record listing.
+// Restricts records to the zone and, if specified, to the server-side name condition.
+// Parameters:
+//   - zone: The zone to list records for
+//   - nameFilter: Server-side record name condition (may be empty)
+//
+// Returns:
+//   - SQL-like WHERE clause string for API filtering
+func buildRecordWhereClause(zone ZoneAuth, nameFilter string) string {
+	where := "zone_id=" + zone.ID
+	if nameFilter != "" {
+		where += " AND " + nameFilter
 	}
 	return where
 }
@@ -244,7 +832,7 @@ func convertZoneData(zones []eip.DataInnerDnsZoneData) []*ZoneAuth {
 }
 
 // convertRecordsToEndpoints transforms API records to external-dns endpoints.
-// Handles different record types (A, TXT, CNAME) and combines A records with multiple targets.
+// Handles different record types (A, AAAA, MX, SRV, TXT, CNAME) and combines records with multiple targets.
 // Parameters:
 //   - records: Slice of API record data objects
 //
@@ -252,9 +840,29 @@ func convertZoneData(zones []eip.DataInnerDnsZoneData) []*ZoneAuth {
 //   - Slice of endpoint objects
 //   - Error if any record processing fails (though currently always returns nil error)
 func convertRecordsToEndpoints(records []eip.DataInnerDnsRrData) ([]*endpoint.Endpoint, error) {
-	var endpoints []*endpoint.Endpoint
-	hostRecords := make(map[string]*endpoint.Endpoint)
+	converter := newRecordConverter()
+	converter.add(records)
+	return converter.result(), nil
+}
+
+// recordConverter accumulates API records page by page into external-dns endpoints.
+// Records with multiple targets are grouped across pages.
+type recordConverter struct {
+	endpoints   []*endpoint.Endpoint          // Endpoints in listing order
+	hostRecords map[string]*endpoint.Endpoint // Grouped endpoints by name and type
+}
+
+// newRecordConverter creates an empty record converter
+func newRecordConverter() *recordConverter {
+	return &recordConverter{
+		hostRecords: make(map[string]*endpoint.Endpoint),
+	}
+}
 
+// add converts a page of API records and merges it into the result.
+// Parameters:
+//   - records: Slice of API record data objects
+func (c *recordConverter) add(records []eip.DataInnerDnsRrData) {
 	for _, rr := range records {
 		ttl, err := strconv.Atoi(rr.GetRrTtl())
 		if err != nil {
@@ -263,40 +871,50 @@ func convertRecordsToEndpoints(records []eip.DataInnerDnsRrData) ([]*endpoint.En
 		}
 
 		switch rr.GetRrType() {
-		case "A":
-			handleARecord(rr, ttl, hostRecords)
+		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeMX, endpoint.RecordTypeSRV:
+			if ep := handleGroupedRecord(rr, ttl, c.hostRecords); ep != nil {
+				c.endpoints = append(c.endpoints, ep)
+			}
 		case "TXT", "CNAME":
-			endpoints = append(endpoints, createStandardEndpoint(rr, ttl))
+			c.endpoints = append(c.endpoints, createStandardEndpoint(rr, ttl))
 		default:
 			log.Debugf("Skipping unsupported record type %s for %s", rr.GetRrType(), rr.GetRrFullName())
 		}
 	}
-	// Add all A records to the final endpoints
-	for _, record := range hostRecords {
-		endpoints = append(endpoints, record)
-	}
+}
 
-	return endpoints, nil
+// result returns all endpoints converted so far
+func (c *recordConverter) result() []*endpoint.Endpoint {
+	return c.endpoints
 }
 
-// handleARecord processes A records with potential multiple targets.
-// Groups A records by name and combines their targets.
+// handleGroupedRecord processes A, AAAA, MX and SRV records with potential multiple targets.
+// Groups records by name and type and combines their targets and ownership labels.
 // Parameters:
 //   - rr: API record data object
 //   - ttl: TTL value for the record
-//   - hostRecords: Map to store and group A records by name
-func handleARecord(rr eip.DataInnerDnsRrData, ttl int, hostRecords map[string]*endpoint.Endpoint) {
-	key := rr.GetRrFullName() + ":A"
+//   - hostRecords: Map to store and group records by name and type
+//
+// Returns:
+//   - The new endpoint if this is the first record of its group, nil otherwise
+func handleGroupedRecord(rr eip.DataInnerDnsRrData, ttl int, hostRecords map[string]*endpoint.Endpoint) *endpoint.Endpoint {
+	key := rr.GetRrFullName() + ":" + rr.GetRrType()
+	target := recordTarget(rr)
 	if existing, found := hostRecords[key]; found {
-		existing.Targets = append(existing.Targets, rr.GetRrAllValue())
-	} else {
-		hostRecords[key] = endpoint.NewEndpointWithTTL(
-			rr.GetRrFullName(),
-			endpoint.RecordTypeA,
-			endpoint.TTL(ttl),
-			rr.GetRrAllValue(),
-		)
+		existing.Targets = append(existing.Targets, target)
+		labelsFromClassParameters(existing.Labels, rr.GetRrClassParameters())
+		return nil
 	}
+
+	ep := endpoint.NewEndpointWithTTL(
+		rr.GetRrFullName(),
+		rr.GetRrType(),
+		endpoint.TTL(ttl),
+		target,
+	)
+	labelsFromClassParameters(ep.Labels, rr.GetRrClassParameters())
+	hostRecords[key] = ep
+	return ep
 }
 
 // createStandardEndpoint creates an endpoint for standard record types (TXT, CNAME).
@@ -307,10 +925,12 @@ func handleARecord(rr eip.DataInnerDnsRrData, ttl int, hostRecords map[string]*e
 // Returns:
 //   - New endpoint object representing the record
 func createStandardEndpoint(rr eip.DataInnerDnsRrData, ttl int) *endpoint.Endpoint {
-	return endpoint.NewEndpointWithTTL(
+	ep := endpoint.NewEndpointWithTTL(
 		rr.GetRrFullName(),
 		rr.GetRrType(),
 		endpoint.TTL(ttl),
 		rr.GetRrAllValue(),
 	)
+	labelsFromClassParameters(ep.Labels, rr.GetRrClassParameters())
+	return ep
 }
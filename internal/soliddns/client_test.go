@@ -0,0 +1,914 @@
+package soliddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// stubCall is a SOLIDserver API call received by a stubbed API
+type stubCall struct {
+	ctx    context.Context // Context of the HTTP request
+	method string
+	path   string            // Service path, e.g. /dns/rr/edit
+	params map[string]string // Query and JSON body parameters
+}
+
+// String formats the call as "<method> <path> <param>=<value>..." with sorted parameters
+func (c stubCall) String() string {
+	keys := make([]string, 0, len(c.params))
+	for key := range c.params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{c.method, c.path}
+	for _, key := range keys {
+		parts = append(parts, key+"="+c.params[key])
+	}
+	return strings.Join(parts, " ")
+}
+
+// stubServer answers the calls of a stubbed API and records them
+type stubServer struct {
+	mu      sync.Mutex
+	calls   []stubCall
+	handler func(call stubCall) (int, string)
+}
+
+// RoundTrip records the call and returns the response of the handler
+func (s *stubServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := stubCall{ctx: req.Context(), method: req.Method, path: strings.TrimPrefix(req.URL.Path, "/api/v2.0"), params: map[string]string{}}
+	for key, values := range req.URL.Query() {
+		call.params[key] = strings.Join(values, ",")
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]any
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &fields); err != nil {
+				return nil, err
+			}
+		}
+		for key, value := range fields {
+			if text, ok := value.(string); ok {
+				call.params[key] = text
+				continue
+			}
+			encoded, _ := json.Marshal(value)
+			call.params[key] = string(encoded)
+		}
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	s.mu.Unlock()
+
+	status, body := http.StatusOK, `{"success":true}`
+	if s.handler != nil {
+		status, body = s.handler(call)
+	}
+	// Like a real transport, give up on requests whose context ended
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// recorded returns the calls received so far, formatted with stubCall.String
+func (s *stubServer) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]string, 0, len(s.calls))
+	for _, call := range s.calls {
+		calls = append(calls, call.String())
+	}
+	return calls
+}
+
+// newStubbedAPI creates an EfficientIPAPI whose SOLIDserver calls are answered by handler.
+// Calls go through the API client as in production; a nil handler answers every call with success.
+func newStubbedAPI(t *testing.T, config *EfficientIPConfig, handler func(call stubCall) (int, string)) (*EfficientIPAPI, *stubServer) {
+	t.Helper()
+	if config.Host == "" {
+		config.Host, config.Port = "sds", 443
+	}
+	pool, err := newEndpointPool(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &credentialStore{}
+	store.current.Store(&credentials{basicAuth: &eip.BasicAuth{UserName: "user", Password: "pass"}})
+
+	server := &stubServer{handler: handler}
+	clientConfig := eip.NewConfiguration()
+	clientConfig.HTTPClient = &http.Client{Transport: server}
+	api := NewEfficientIPAPI(clientConfig, config, store, pool)
+	return &api, server
+}
+
+// recordListResponse encodes records as the body of a successful list call
+func recordListResponse(records ...eip.DataInnerDnsRrData) string {
+	body, _ := json.Marshal(eip.DnsRrData{Success: eip.PtrBool(true), Data: records})
+	return string(body)
+}
+
+type recordTestCase struct {
+	name     string
+	records  []eip.DataInnerDnsRrData
+	expected []*endpoint.Endpoint
+}
+
+func newRecordData(name, recordType, ttl, allValue string, values ...string) eip.DataInnerDnsRrData {
+	rr := eip.NewDataInnerDnsRrData()
+	rr.SetRrFullName(name)
+	rr.SetRrType(recordType)
+	rr.SetRrTtl(ttl)
+	rr.SetRrAllValue(allValue)
+	setters := []func(string){rr.SetRrValue1, rr.SetRrValue2, rr.SetRrValue3, rr.SetRrValue4}
+	for i, value := range values {
+		setters[i](value)
+	}
+	return *rr
+}
+
+func TestConvertRecordsToEndpoints(t *testing.T) {
+	testCases := []recordTestCase{
+		{
+			name: "A records grouped by name",
+			records: []eip.DataInnerDnsRrData{
+				newRecordData("www.example.com", "A", "300", "192.0.2.1"),
+				newRecordData("www.example.com", "A", "300", "192.0.2.2"),
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2"),
+			},
+		},
+		{
+			name: "AAAA records grouped by name and normalized",
+			records: []eip.DataInnerDnsRrData{
+				newRecordData("www.example.com", "AAAA", "600", "2001:0db8:0000:0000:0000:0000:0000:0001"),
+				newRecordData("www.example.com", "AAAA", "600", "0:0::1"),
+				newRecordData("www.example.com", "A", "600", "192.0.2.1"),
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 600, "192.0.2.1"),
+				endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeAAAA, 600, "2001:db8::1", "::1"),
+			},
+		},
+		{
+			name: "MX records rebuilt from preference and exchange",
+			records: []eip.DataInnerDnsRrData{
+				newRecordData("example.com", "MX", "3600", "10 mx1.example.com", "10", "mx1.example.com"),
+				newRecordData("example.com", "MX", "3600", "20 mx2.example.com.", "20", "mx2.example.com."),
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeMX, 3600, "10 mx1.example.com", "20 mx2.example.com"),
+			},
+		},
+		{
+			name: "SRV records rebuilt from all four values",
+			records: []eip.DataInnerDnsRrData{
+				newRecordData("_sip._tcp.example.com", "SRV", "300", "10 5 5060 sip1.example.com", "10", "5", "5060", "sip1.example.com"),
+				newRecordData("_sip._tcp.example.com", "SRV", "300", "20 0 5060 sip2.example.com", "20", "0", "5060", "sip2.example.com."),
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("_sip._tcp.example.com", endpoint.RecordTypeSRV, 300, "10 5 5060 sip1.example.com", "20 0 5060 sip2.example.com"),
+			},
+		},
+		{
+			name: "invalid TTL falls back to default",
+			records: []eip.DataInnerDnsRrData{
+				newRecordData("alias.example.com", "CNAME", "abc", "www.example.com"),
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("alias.example.com", endpoint.RecordTypeCNAME, 300, "www.example.com"),
+			},
+		},
+		{
+			name: "unsupported record type skipped",
+			records: []eip.DataInnerDnsRrData{
+				newRecordData("example.com", "SOA", "3600", "ns1.example.com"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := convertRecordsToEndpoints(tc.records)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sortEndpoints(actual)
+			sortEndpoints(tc.expected)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected endpoints %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordConverterPages(t *testing.T) {
+	converter := newRecordConverter()
+	converter.add([]eip.DataInnerDnsRrData{
+		newRecordData("a.example.com", "A", "300", "192.0.2.1"),
+		newRecordData("b.example.com", "A", "300", "192.0.2.3"),
+	})
+	converter.add([]eip.DataInnerDnsRrData{
+		newRecordData("b.example.com", "A", "300", "192.0.2.4"),
+		newRecordData("c.example.com", "CNAME", "300", "a.example.com"),
+	})
+
+	expected := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("a.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+		endpoint.NewEndpointWithTTL("b.example.com", endpoint.RecordTypeA, 300, "192.0.2.3", "192.0.2.4"),
+		endpoint.NewEndpointWithTTL("c.example.com", endpoint.RecordTypeCNAME, 300, "a.example.com"),
+	}
+	if actual := converter.result(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected endpoints %v, got %v", expected, actual)
+	}
+}
+
+// pagedResponses answers list calls with the page of items selected by their offset and limit parameters
+func pagedResponses[T any](items []T, encode func(page []T) string) func(call stubCall) (int, string) {
+	return func(call stubCall) (int, string) {
+		offset, limit := 0, len(items)
+		if value, found := call.params["offset"]; found {
+			offset, _ = strconv.Atoi(value)
+		}
+		if value, found := call.params["limit"]; found {
+			limit, _ = strconv.Atoi(value)
+		}
+		start, end := min(offset, len(items)), min(offset+limit, len(items))
+		return http.StatusOK, encode(items[start:end])
+	}
+}
+
+// pageParams returns the offset and limit parameters of the recorded calls as "offset/limit"
+func pageParams(server *stubServer) []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	var pages []string
+	for _, call := range server.calls {
+		pages = append(pages, call.params["offset"]+"/"+call.params["limit"])
+	}
+	return pages
+}
+
+func TestZonesListPages(t *testing.T) {
+	var zones []eip.DataInnerDnsZoneData
+	for i := 0; i < 5; i++ {
+		zone := eip.NewDataInnerDnsZoneData()
+		zone.SetZoneName(fmt.Sprintf("zone%d.example.com", i))
+		zone.SetZoneId(strconv.Itoa(i))
+		zones = append(zones, *zone)
+	}
+	encode := func(page []eip.DataInnerDnsZoneData) string {
+		body, _ := json.Marshal(eip.DnsZoneData{Success: eip.PtrBool(true), Data: page})
+		return string(body)
+	}
+
+	testCases := []struct {
+		name       string
+		maxResults int
+		zones      int
+		pages      []string
+	}{
+		{name: "short last page", maxResults: 2, zones: 5, pages: []string{"0/2", "2/2", "4/2"}},
+		{name: "full last page", maxResults: 2, zones: 4, pages: []string{"0/2", "2/2", "4/2"}},
+		{name: "single page", maxResults: 10, zones: 5, pages: []string{"0/10"}},
+		{name: "paging disabled", maxResults: 0, zones: 5, pages: []string{"/"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &EfficientIPConfig{DnsSmart: "smart", MaxResults: tc.maxResults}
+			api, server := newStubbedAPI(t, config, pagedResponses(zones[:tc.zones], encode))
+
+			result, err := api.ZonesList(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result) != tc.zones {
+				t.Errorf("expected %d zones, got %d", tc.zones, len(result))
+			}
+			for i, zone := range result {
+				if zone.ID != strconv.Itoa(i) {
+					t.Errorf("zone %d: expected ID %d, got %+v", i, i, zone)
+				}
+			}
+			if actual := pageParams(server); !reflect.DeepEqual(actual, tc.pages) {
+				t.Errorf("expected pages %v, got %v", tc.pages, actual)
+			}
+			// Pages only line up if the order is total
+			for _, call := range server.calls {
+				if order := call.params["orderby"]; order != "zone_name,zone_id" {
+					t.Errorf("expected zones ordered by name and id, got %q", order)
+				}
+			}
+		})
+	}
+}
+
+func TestZonesListViews(t *testing.T) {
+	zone := func(name, id, view string) eip.DataInnerDnsZoneData {
+		z := eip.NewDataInnerDnsZoneData()
+		z.SetZoneName(name)
+		z.SetZoneId(id)
+		z.SetViewName(view)
+		return *z
+	}
+	handler := func(call stubCall) (int, string) {
+		zones := []eip.DataInnerDnsZoneData{zone("example.com", "1", "internal"), zone("example.com", "2", "external")}
+		if strings.Contains(call.params["where"], "view_name='internal'") {
+			zones = zones[:1]
+		}
+		body, _ := json.Marshal(eip.DnsZoneData{Success: eip.PtrBool(true), Data: zones})
+		return http.StatusOK, string(body)
+	}
+
+	testCases := []struct {
+		name     string
+		view     string
+		where    string
+		expected []string
+	}{
+		{name: "configured view", view: "internal", where: "server_name='smart' AND view_name='internal'", expected: []string{"smart/internal"}},
+		{name: "no view", where: "server_name='smart'", expected: []string{"smart/internal", "smart/external"}},
+	}
+
+	for _, tc := range testCases {
+		config := &EfficientIPConfig{DnsSmart: "smart", DnsView: tc.view}
+		api, server := newStubbedAPI(t, config, handler)
+
+		zones, err := api.ZonesList(context.Background(), config)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		var scopes []string
+		for _, zone := range zones {
+			scopes = append(scopes, zone.scope().String())
+		}
+		if !reflect.DeepEqual(scopes, tc.expected) {
+			t.Errorf("%s: expected zones in %v, got %v", tc.name, tc.expected, scopes)
+		}
+		if where := server.calls[0].params["where"]; !strings.HasPrefix(where, tc.where) {
+			t.Errorf("%s: expected filter %q, got %q", tc.name, tc.where, where)
+		}
+	}
+}
+
+func TestBuildRecordWhereClause(t *testing.T) {
+	zone := ZoneAuth{Name: "example.com", ID: "7"}
+	testCases := []struct {
+		nameFilter string
+		expected   string
+	}{
+		{expected: "zone_id=7"},
+		{nameFilter: "(rr_full_name LIKE 'www.example.com')", expected: "zone_id=7 AND (rr_full_name LIKE 'www.example.com')"},
+	}
+
+	for _, tc := range testCases {
+		if actual := buildRecordWhereClause(zone, tc.nameFilter); actual != tc.expected {
+			t.Errorf("buildRecordWhereClause(%q): expected %q, got %q", tc.nameFilter, tc.expected, actual)
+		}
+	}
+}
+
+func TestRecordListNameFilter(t *testing.T) {
+	testCases := []struct {
+		nameRegEx string
+		expected  string
+	}{
+		{nameRegEx: "", expected: "zone_id=7"},
+		{nameRegEx: `^api-[0-9]+\.example\.com$`, expected: "zone_id=7 AND (rr_full_name LIKE 'api-%.example.com')"},
+		// Regexes without LIKE equivalent are applied client-side only
+		{nameRegEx: `(?i)^API`, expected: "zone_id=7"},
+	}
+
+	for _, tc := range testCases {
+		api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart", NameRegEx: tc.nameRegEx}, func(call stubCall) (int, string) {
+			return http.StatusOK, recordListResponse()
+		})
+
+		if _, err := api.RecordList(context.Background(), ZoneAuth{Name: "example.com", ID: "7", Smart: "smart"}); err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.nameRegEx, err)
+		}
+		if where := server.calls[0].params["where"]; where != tc.expected {
+			t.Errorf("%q: expected filter %q, got %q", tc.nameRegEx, tc.expected, where)
+		}
+	}
+}
+
+func TestRecordListPages(t *testing.T) {
+	records := []eip.DataInnerDnsRrData{
+		newRecordData("a.example.com", "A", "300", "192.0.2.1", "192.0.2.1"),
+		newRecordData("b.example.com", "A", "300", "192.0.2.2", "192.0.2.2"),
+		newRecordData("b.example.com", "A", "300", "192.0.2.3", "192.0.2.3"),
+		newRecordData("c.example.com", "CNAME", "300", "a.example.com", "a.example.com"),
+	}
+	for i := range records {
+		records[i].SetRrId(strconv.Itoa(i + 1))
+	}
+	encode := func(page []eip.DataInnerDnsRrData) string {
+		return recordListResponse(page...)
+	}
+
+	testCases := []struct {
+		name       string
+		maxResults int
+		pages      []string
+	}{
+		{name: "record groups spanning pages", maxResults: 2, pages: []string{"0/2", "2/2", "4/2"}},
+		{name: "short last page", maxResults: 3, pages: []string{"0/3", "3/3"}},
+		{name: "paging disabled", maxResults: 0, pages: []string{"/"}},
+	}
+
+	expected := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("a.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+		endpoint.NewEndpointWithTTL("b.example.com", endpoint.RecordTypeA, 300, "192.0.2.2", "192.0.2.3"),
+		endpoint.NewEndpointWithTTL("c.example.com", endpoint.RecordTypeCNAME, 300, "a.example.com"),
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart", MaxResults: tc.maxResults}, pagedResponses(records, encode))
+			zone := ZoneAuth{Name: "example.com", ID: "7", Smart: "smart"}
+
+			actual, err := api.RecordList(context.Background(), zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, ep := range actual {
+				ep.ProviderSpecific = nil
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected endpoints %v, got %v", expected, actual)
+			}
+			if pages := pageParams(server); !reflect.DeepEqual(pages, tc.pages) {
+				t.Errorf("expected pages %v, got %v", tc.pages, pages)
+			}
+			for _, call := range server.calls {
+				if order := call.params["orderby"]; order != "rr_full_name,rr_id" {
+					t.Errorf("expected records ordered by name and id, got %q", order)
+				}
+			}
+			// Records of every page are indexed for later changes
+			if ids := api.index.recordIDs(zone.scope(), "b.example.com", endpoint.RecordTypeA, "192.0.2.3"); !reflect.DeepEqual(ids, []int32{3}) {
+				t.Errorf("expected rr_id 3 of the last b.example.com record, got %v", ids)
+			}
+		})
+	}
+}
+
+func TestRecordAddExistingRecord(t *testing.T) {
+	ep := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+	conflict := `{"success":false,"errno":"2010","errmsg":"Record already exists","parameter":"rr_name"}`
+
+	t.Run("identical record", func(t *testing.T) {
+		existing := newRecordData("www.example.com", "A", "300", "192.0.2.1", "192.0.2.1")
+		existing.SetRrId("42")
+		api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+			if call.path == "/dns/rr/add" {
+				return http.StatusBadRequest, conflict
+			}
+			return http.StatusOK, recordListResponse(existing)
+		})
+
+		if err := api.RecordAdd(context.Background(), ep); err != nil {
+			t.Fatalf("expected an existing identical record to be a no-op, got %v", err)
+		}
+		calls := server.recorded()
+		if len(calls) != 2 || !strings.HasPrefix(calls[0], "POST /dns/rr/add") || !strings.HasPrefix(calls[1], "GET /dns/rr/list") {
+			t.Fatalf("expected a creation followed by a lookup, got %q", calls)
+		}
+		if where := server.calls[1].params["where"]; !strings.Contains(where, "rr_value1='192.0.2.1'") || !strings.Contains(where, "server_name='smart'") {
+			t.Errorf("expected the lookup to match the record values in the smart, got %q", where)
+		}
+	})
+
+	t.Run("conflicting record", func(t *testing.T) {
+		api, _ := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+			if call.path == "/dns/rr/add" {
+				return http.StatusBadRequest, conflict
+			}
+			// No record of that name holds the value
+			return http.StatusOK, recordListResponse()
+		})
+
+		err := api.RecordAdd(context.Background(), ep)
+		if !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected a conflict error, got %v", err)
+		}
+	})
+}
+
+func TestRecordDeleteByAttributesScope(t *testing.T) {
+	testCases := []struct {
+		name     string
+		indexed  bool
+		expected string
+	}{
+		{
+			name:     "listed zone",
+			indexed:  true,
+			expected: "DELETE /dns/rr/delete rr_name=www.example.com rr_type=A rr_value1=192.0.2.1 server_name=smart view_name=internal zone_name=example.com",
+		},
+		{
+			name:     "unknown zone",
+			expected: "DELETE /dns/rr/delete rr_name=www.example.com rr_type=A rr_value1=192.0.2.1 server_name=smart view_name=internal",
+		},
+	}
+
+	for _, tc := range testCases {
+		api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart", DnsView: "internal"}, nil)
+		if tc.indexed {
+			// Listed without rr_id, so the record is deleted by attributes within its zone
+			api.index.setRecord(recordScope{smart: "smart", view: "internal"}, "example.com", "www.example.com", endpoint.RecordTypeA, "192.0.2.1", 0)
+		}
+
+		ep := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+		if err := api.RecordDelete(context.Background(), ep); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if calls := server.recorded(); len(calls) != 1 || calls[0] != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, calls)
+		}
+	}
+}
+
+func TestRecordDeleteMissingRecord(t *testing.T) {
+	ep := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+	testCases := []struct {
+		name     string
+		id       int32
+		response string
+		success  bool
+	}{
+		{name: "record deleted by rr_id", id: 42, response: `{"errno":"2021","errmsg":"Not found","parameter":"rr_id"}`, success: true},
+		{name: "record deleted by attributes", response: `{"errno":"2021","errmsg":"The RR does not exist"}`, success: true},
+		{name: "view not found", response: `{"errno":"2040","errmsg":"View not found","parameter":"view_name"}`},
+		{name: "zone not found", response: `{"errno":"2041","errmsg":"Zone does not exist"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart", DnsView: "internal"}, func(call stubCall) (int, string) {
+				return http.StatusBadRequest, tc.response
+			})
+			if tc.id != 0 {
+				api.index.setRecord(recordScope{smart: "smart", view: "internal"}, "example.com", "www.example.com", endpoint.RecordTypeA, "192.0.2.1", tc.id)
+			}
+
+			err := api.RecordDelete(context.Background(), ep)
+			if tc.success && err != nil {
+				t.Errorf("expected a missing record to count as deleted, got %v", err)
+			}
+			if !tc.success && !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected the not-found error to be returned, got %v", err)
+			}
+			if calls := server.recorded(); len(calls) != 1 || !strings.HasPrefix(calls[0], "DELETE /dns/rr/delete") {
+				t.Errorf("expected a single deletion, got %q", calls)
+			}
+		})
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	pool, err := newEndpointPool(&EfficientIPConfig{Host: "sds1", Port: 8443})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, _ := pool.current()
+
+	basic := &credentialStore{}
+	basic.current.Store(&credentials{basicAuth: &eip.BasicAuth{UserName: "user", Password: "pass"}})
+	token := &credentialStore{}
+	token.current.Store(&credentials{tokenAuth: &eip.EipApiTokenAuth{Token: "token", Secret: "secret"}})
+
+	api := EfficientIPAPI{credentials: basic, endpoints: pool, requestTimeout: time.Minute}
+	ctx, cancel := api.requestContext(context.Background(), node)
+	defer cancel()
+
+	if auth, ok := ctx.Value(eip.ContextBasicAuth).(eip.BasicAuth); !ok || auth.UserName != "user" || auth.Password != "pass" {
+		t.Errorf("expected basic auth in the request context, got %v", ctx.Value(eip.ContextBasicAuth))
+	}
+	if variables, ok := ctx.Value(eip.ContextServerVariables).(map[string]string); !ok || variables["host"] != "sds1" || variables["port"] != "8443" {
+		t.Errorf("expected the server variables of sds1:8443, got %v", ctx.Value(eip.ContextServerVariables))
+	}
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("expected a deadline within the request timeout, got %v (%t)", deadline, ok)
+	}
+
+	api = EfficientIPAPI{credentials: token, endpoints: pool}
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel = api.requestContext(parent, node)
+	defer cancel()
+
+	if auth, ok := ctx.Value(eip.ContextEipApiTokenAuth).(eip.EipApiTokenAuth); !ok || auth.Token != "token" || auth.Secret != "secret" {
+		t.Errorf("expected token auth in the request context, got %v", ctx.Value(eip.ContextEipApiTokenAuth))
+	}
+	if ctx.Value(eip.ContextBasicAuth) != nil {
+		t.Error("expected no basic auth with token credentials")
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline without request timeout")
+	}
+	cancelParent()
+	if ctx.Err() == nil {
+		t.Error("expected cancelling the caller to cancel the request context")
+	}
+}
+
+func TestRecordAddAborts(t *testing.T) {
+	// The stubbed appliance never answers before the request context ends
+	hang := func(call stubCall) (int, string) {
+		<-call.ctx.Done()
+		return http.StatusOK, `{"success":true}`
+	}
+	ep := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+
+	t.Run("request timeout", func(t *testing.T) {
+		api, _ := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart", RequestTimeout: 20 * time.Millisecond}, hang)
+
+		start := time.Now()
+		err := api.RecordAdd(context.Background(), ep)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the request timeout to abort the call, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("expected the call to be aborted after the request timeout, took %s", elapsed)
+		}
+	})
+
+	t.Run("caller cancelled", func(t *testing.T) {
+		api, _ := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, hang)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		if err := api.RecordAdd(ctx, ep); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected cancelling the caller to abort the call, got %v", err)
+		}
+	})
+}
+
+func TestNormalizeTarget(t *testing.T) {
+	testCases := []struct {
+		recordType string
+		target     string
+		expected   string
+	}{
+		{endpoint.RecordTypeAAAA, "0:0::1", "::1"},
+		{endpoint.RecordTypeAAAA, "2001:DB8::0:1", "2001:db8::1"},
+		{endpoint.RecordTypeAAAA, "not-an-ip", "not-an-ip"},
+		{endpoint.RecordTypeA, "192.0.2.1", "192.0.2.1"},
+		{endpoint.RecordTypeCNAME, "0:0::1", "0:0::1"},
+		{endpoint.RecordTypeMX, "10   mail.example.com.", "10 mail.example.com"},
+		{endpoint.RecordTypeMX, "mail.example.com", "mail.example.com"},
+	}
+
+	for _, tc := range testCases {
+		if actual := normalizeTarget(tc.recordType, tc.target); actual != tc.expected {
+			t.Errorf("normalizeTarget(%s, %s): expected %s, got %s", tc.recordType, tc.target, tc.expected, actual)
+		}
+	}
+}
+
+func TestRecordValues(t *testing.T) {
+	testCases := []struct {
+		recordType string
+		target     string
+		expected   []string
+		hasError   bool
+	}{
+		{recordType: endpoint.RecordTypeA, target: "192.0.2.1", expected: []string{"192.0.2.1"}},
+		{recordType: endpoint.RecordTypeMX, target: "10 mail.example.com", expected: []string{"10", "mail.example.com"}},
+		{recordType: endpoint.RecordTypeMX, target: "mail.example.com", hasError: true},
+		{recordType: endpoint.RecordTypeMX, target: "high mail.example.com", hasError: true},
+		{recordType: endpoint.RecordTypeMX, target: "70000 mail.example.com", hasError: true},
+		{recordType: endpoint.RecordTypeSRV, target: "10 5 443 svc.example.com.", expected: []string{"10", "5", "443", "svc.example.com"}},
+		{recordType: endpoint.RecordTypeSRV, target: "10 5 svc.example.com", hasError: true},
+		{recordType: endpoint.RecordTypeSRV, target: "10 5 https svc.example.com", hasError: true},
+	}
+
+	for _, tc := range testCases {
+		actual, err := recordValues(tc.recordType, tc.target)
+		if tc.hasError {
+			if err == nil {
+				t.Errorf("recordValues(%s, %s): expected error, got %v", tc.recordType, tc.target, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("recordValues(%s, %s): unexpected error: %v", tc.recordType, tc.target, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("recordValues(%s, %s): expected %v, got %v", tc.recordType, tc.target, tc.expected, actual)
+		}
+	}
+}
+
+func TestDiffTargets(t *testing.T) {
+	common, removed, added := diffTargets(endpoint.RecordTypeAAAA,
+		[]string{"2001:db8::1", "2001:db8::2", "2001:db8::3"},
+		[]string{"2001:db8:0::1", "2001:db8::4"},
+	)
+
+	if !reflect.DeepEqual(common, []string{"2001:db8::1"}) {
+		t.Errorf("expected common targets [2001:db8::1], got %v", common)
+	}
+	if !reflect.DeepEqual(removed, []string{"2001:db8::2", "2001:db8::3"}) {
+		t.Errorf("expected removed targets [2001:db8::2 2001:db8::3], got %v", removed)
+	}
+	if !reflect.DeepEqual(added, []string{"2001:db8::4"}) {
+		t.Errorf("expected added targets [2001:db8::4], got %v", added)
+	}
+}
+
+func TestRecordUpdate(t *testing.T) {
+	scope := recordScope{smart: "smart"}
+	testCases := []struct {
+		name     string
+		current  *endpoint.Endpoint
+		desired  *endpoint.Endpoint
+		expected []string
+	}{
+		{
+			name:    "TTL only",
+			current: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2"),
+			desired: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 600, "192.0.2.2", "192.0.2.1"),
+			expected: []string{
+				"PUT /dns/rr/edit rr_id=11 rr_ttl=600 rr_value1=192.0.2.2",
+				"PUT /dns/rr/edit rr_id=10 rr_ttl=600 rr_value1=192.0.2.1",
+			},
+		},
+		{
+			name:    "target rewritten in place",
+			current: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			desired: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.9"),
+			expected: []string{
+				"PUT /dns/rr/edit rr_id=10 rr_ttl=300 rr_value1=192.0.2.9",
+			},
+		},
+		{
+			name:    "surplus current targets deleted",
+			current: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2", "192.0.2.3"),
+			desired: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.9"),
+			expected: []string{
+				"PUT /dns/rr/edit rr_id=11 rr_ttl=300 rr_value1=192.0.2.9",
+				"DELETE /dns/rr/delete rr_id=12",
+			},
+		},
+		{
+			name:    "surplus desired targets created",
+			current: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			desired: endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.9"),
+			expected: []string{
+				"POST /dns/rr/add rr_name=www.example.com rr_ttl=300 rr_type=A rr_value1=192.0.2.9 server_name=smart view_name=",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, nil)
+			for i, target := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+				api.index.setRecord(scope, "example.com", "www.example.com", endpoint.RecordTypeA, target, int32(10+i))
+			}
+
+			if err := api.RecordUpdate(context.Background(), tc.current, tc.desired); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := server.recorded(); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected calls %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordUpdateLooksUpUnknownRecordID(t *testing.T) {
+	listed := newRecordData("www.example.com", "A", "300", "192.0.2.1", "192.0.2.1")
+	listed.SetRrId("42")
+	api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+		if call.path == "/dns/rr/list" {
+			return http.StatusOK, recordListResponse(listed)
+		}
+		return http.StatusOK, `{"success":true}`
+	})
+
+	current := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+	desired := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.9")
+	if err := api.RecordUpdate(context.Background(), current, desired); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := server.recorded()
+	if len(calls) != 2 || !strings.HasPrefix(calls[0], "GET /dns/rr/list") {
+		t.Fatalf("expected a lookup followed by an update, got %q", calls)
+	}
+	if expected := "PUT /dns/rr/edit rr_id=42 rr_ttl=300 rr_value1=192.0.2.9"; calls[1] != expected {
+		t.Errorf("expected %q, got %q", expected, calls[1])
+	}
+}
+
+func TestRecordUpdateUnknownRecord(t *testing.T) {
+	api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+		return http.StatusOK, recordListResponse()
+	})
+
+	current := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+	desired := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.9")
+	if err := api.RecordUpdate(context.Background(), current, desired); err == nil {
+		t.Fatal("expected an error for a record that cannot be looked up")
+	}
+	if calls := server.recorded(); len(calls) != 1 || !strings.HasPrefix(calls[0], "GET /dns/rr/list") {
+		t.Errorf("expected only the lookup, got %q", calls)
+	}
+}
+
+func TestLookupRecordIDMatchesListedRecord(t *testing.T) {
+	other := newRecordData("www.example.com", "A", "300", "192.0.2.2", "192.0.2.2")
+	other.SetRrId("41")
+	listed := newRecordData("WWW.example.com", "A", "300", "192.0.2.1", "192.0.2.1")
+	listed.SetRrId("42")
+
+	testCases := []struct {
+		name     string
+		records  []eip.DataInnerDnsRrData
+		expected int32
+		hasError bool
+	}{
+		{name: "skips rows that do not match", records: []eip.DataInnerDnsRrData{other, listed}, expected: 42},
+		{name: "no row matches", records: []eip.DataInnerDnsRrData{other}, hasError: true},
+	}
+
+	for _, tc := range testCases {
+		api, _ := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+			return http.StatusOK, recordListResponse(tc.records...)
+		})
+
+		ep := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+		actual, err := api.lookupRecordID(context.Background(), recordScope{smart: "smart"}, ep, "192.0.2.1")
+		if tc.hasError {
+			if err == nil {
+				t.Errorf("%s: expected error, got rr_id %d", tc.name, actual)
+			}
+			continue
+		}
+		if err != nil || actual != tc.expected {
+			t.Errorf("%s: expected rr_id %d, got %d (%v)", tc.name, tc.expected, actual, err)
+		}
+	}
+}
+
+func TestLookupRecordIDRejectsQuotes(t *testing.T) {
+	testCases := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("www.example.com' OR '1'='1", endpoint.RecordTypeA, 300, "192.0.2.1"),
+		endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeTXT, 300, `"it's"`),
+		endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeTXT, 300, `back\slash`),
+	}
+
+	for _, ep := range testCases {
+		api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+			return http.StatusOK, recordListResponse()
+		})
+
+		if _, err := api.lookupRecordID(context.Background(), recordScope{smart: "smart"}, ep, ep.Targets[0]); err == nil {
+			t.Errorf("%s %s: expected error", ep.DNSName, ep.Targets[0])
+		}
+		if calls := server.recorded(); len(calls) != 0 {
+			t.Errorf("%s %s: expected no API calls, got %q", ep.DNSName, ep.Targets[0], calls)
+		}
+	}
+}
+
+func sortEndpoints(endpoints []*endpoint.Endpoint) {
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].DNSName != endpoints[j].DNSName {
+			return endpoints[i].DNSName < endpoints[j].DNSName
+		}
+		return endpoints[i].RecordType < endpoints[j].RecordType
+	})
+}
@@ -1,64 +1,181 @@
 package soliddns
 
 import (
-	"context"
-	"crypto/tls"
+	"fmt"
 	"net/http"
+	"regexp"
 	"sigs.k8s.io/external-dns/endpoint"
-	"strconv"
+	"slices"
+	"strings"
+	"time"
 
 	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+	log "github.com/sirupsen/logrus"
 )
 
 type EfficientIPConfig struct {
-	Host       string `env:"EIP_HOST,required" envDefault:"localhost"`
-	Port       int    `env:"EIP_PORT,required" envDefault:"443"`
-	Username   string `env:"EIP_USER" envDefault:"ipmadmin"`
-	Password   string `env:"EIP_PASSWORD" envDefault:""`
-	Token      string `env:"EIP_TOKEN" envDefault:""`
-	Secret     string `env:"EIP_SECRET" envDefault:""`
-	DnsSmart   string `env:"EIP_SMART,required"`
-	DnsView    string `env:"EIP_VIEW" envDefault:""`
-	SSLVerify  bool   `env:"EIP_SSL_VERIFY" envDefault:"true"`
-	DryRun     bool   `env:"EIP_DRY_RUN" envDefault:"false"`
-	MaxResults int    `env:"EIP_MAX_RESULTS" envDefault:"1500"`
-	CreatePTR  bool   `env:"EIP_CREATE_PTR" envDefault:"false"`
-	DefaultTTL int    `env:"EIP_DEFAULT_TTL" envDefault:"300"`
-	FQDNRegEx  string
-	NameRegEx  string
+	Host           string        `env:"EIP_HOST,required" envDefault:"localhost"`
+	Port           int           `env:"EIP_PORT,required" envDefault:"443"`
+	Username       string        `env:"EIP_USER" envDefault:"ipmadmin"`
+	Password       string        `env:"EIP_PASSWORD" envDefault:""`
+	Token          string        `env:"EIP_TOKEN" envDefault:""`
+	Secret         string        `env:"EIP_SECRET" envDefault:""`
+	UsernameFile   string        `env:"EIP_USER_FILE" envDefault:""`
+	PasswordFile   string        `env:"EIP_PASSWORD_FILE" envDefault:""`
+	TokenFile      string        `env:"EIP_TOKEN_FILE" envDefault:""`
+	SecretFile     string        `env:"EIP_SECRET_FILE" envDefault:""`
+	DnsSmart       string        `env:"EIP_SMART,required"`
+	DnsView        string        `env:"EIP_VIEW" envDefault:""`
+	SSLVerify      bool          `env:"EIP_SSL_VERIFY" envDefault:"true"`
+	CAFile         string        `env:"EIP_CA_FILE" envDefault:""`
+	ClientCertFile string        `env:"EIP_CLIENT_CERT_FILE" envDefault:""`
+	ClientKeyFile  string        `env:"EIP_CLIENT_KEY_FILE" envDefault:""`
+	TLSServerName  string        `env:"EIP_TLS_SERVER_NAME" envDefault:""`
+	TLSMinVersion  string        `env:"EIP_TLS_MIN_VERSION" envDefault:"1.2"`
+	DryRun         bool          `env:"EIP_DRY_RUN" envDefault:"false"`
+	MaxResults     int           `env:"EIP_MAX_RESULTS" envDefault:"1500"`
+	CreatePTR      bool          `env:"EIP_CREATE_PTR" envDefault:"false"`
+	DefaultTTL     int           `env:"EIP_DEFAULT_TTL" envDefault:"300"`
+	RequestTimeout time.Duration `env:"EIP_REQUEST_TIMEOUT" envDefault:"30s"`
+	ApplyTimeout   time.Duration `env:"EIP_APPLY_TIMEOUT" envDefault:"5m"`
+
+	RetryMaxAttempts        int           `env:"EIP_RETRY_MAX_ATTEMPTS" envDefault:"3"`
+	RetryBaseDelay          time.Duration `env:"EIP_RETRY_BASE_DELAY" envDefault:"500ms"`
+	RetryMaxDelay           time.Duration `env:"EIP_RETRY_MAX_DELAY" envDefault:"10s"`
+	CircuitFailureThreshold int           `env:"EIP_CIRCUIT_FAILURE_THRESHOLD" envDefault:"5"`
+	CircuitResetTimeout     time.Duration `env:"EIP_CIRCUIT_RESET_TIMEOUT" envDefault:"30s"`
+
+	ReadRateLimit  float64 `env:"EIP_READ_RATE_LIMIT" envDefault:"0"`
+	ReadRateBurst  int     `env:"EIP_READ_RATE_BURST" envDefault:"10"`
+	WriteRateLimit float64 `env:"EIP_WRITE_RATE_LIMIT" envDefault:"0"`
+	WriteRateBurst int     `env:"EIP_WRITE_RATE_BURST" envDefault:"5"`
+
+	ListConcurrency  int `env:"EIP_LIST_CONCURRENCY" envDefault:"4"`
+	ApplyConcurrency int `env:"EIP_APPLY_CONCURRENCY" envDefault:"4"`
+
+	ZoneCacheTTL   time.Duration `env:"EIP_ZONE_CACHE_TTL" envDefault:"10m"`
+	RecordCacheTTL time.Duration `env:"EIP_RECORD_CACHE_TTL" envDefault:"1m"`
+
+	CredentialsReloadInterval time.Duration `env:"EIP_CREDENTIALS_RELOAD_INTERVAL" envDefault:"1m"`
+
+	Hosts               []string `env:"EIP_HOSTS" envSeparator:","`
+	FailoverStatusCodes []int    `env:"EIP_FAILOVER_STATUS_CODES" envSeparator:"," envDefault:"502,503,504"`
+
+	FailbackDelay time.Duration `env:"EIP_FAILBACK_DELAY" envDefault:"5m"`
+
+	DnsViews      []string `env:"EIP_VIEWS" envSeparator:","`
+	AllowedViews  []string `env:"EIP_ALLOWED_VIEWS" envSeparator:","`
+	AllowedSmarts []string `env:"EIP_ALLOWED_SMARTS" envSeparator:","`
+	ViewCIDRs     []string `env:"EIP_VIEW_CIDRS" envSeparator:","`
+
+	ViewDefaultPrivate string `env:"EIP_VIEW_DEFAULT_PRIVATE"`
+	ViewDefaultPublic  string `env:"EIP_VIEW_DEFAULT_PUBLIC"`
+
+	FQDNRegEx string
+	NameRegEx string
+}
+
+// views returns the DNS views records are written to by default.
+// EIP_VIEWS takes precedence over EIP_VIEW; without any view a single empty view is returned.
+func (config *EfficientIPConfig) views() []string {
+	views := appendUnique(nil, config.DnsViews...)
+	if len(views) == 0 {
+		return []string{config.DnsView}
+	}
+	return views
+}
+
+// allowedViews returns the views endpoints may select, the default views included
+func (config *EfficientIPConfig) allowedViews() []string {
+	return appendUnique(config.views(), config.AllowedViews...)
+}
+
+// allowedSmarts returns the smarts endpoints may select, the default smart included
+func (config *EfficientIPConfig) allowedSmarts() []string {
+	return appendUnique([]string{config.DnsSmart}, config.AllowedSmarts...)
+}
+
+// defaultScopes returns the scopes of a smart records without view property are written to
+func (config *EfficientIPConfig) defaultScopes(smart string) []recordScope {
+	var scopes []recordScope
+	for _, view := range config.views() {
+		scopes = append(scopes, recordScope{smart: smart, view: view})
+	}
+	return scopes
+}
+
+// listedScopes returns every smart and view combination endpoints may be written to
+func (config *EfficientIPConfig) listedScopes() []recordScope {
+	var scopes []recordScope
+	for _, smart := range config.allowedSmarts() {
+		for _, view := range config.allowedViews() {
+			scopes = append(scopes, recordScope{smart: smart, view: view})
+		}
+	}
+	return scopes
+}
+
+// appendUnique appends the trimmed, non-empty values missing from the list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" && !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
 }
 
 func NewEfficientIPProvider(config *EfficientIPConfig, domainFilter endpoint.DomainFilter) (*Provider, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
 	clientConfig := eip.NewConfiguration()
-	if !config.SSLVerify {
-		customTransport := http.DefaultTransport.(*http.Transport).Clone()
-		customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-		clientConfig.HTTPClient = &http.Client{Transport: customTransport}
+	customTransport := http.DefaultTransport.(*http.Transport).Clone()
+	customTransport.TLSClientConfig = tlsConfig
+	clientConfig.HTTPClient = &http.Client{Transport: customTransport}
+
+	// Without a default view, zones of all views are listed and per-endpoint views would overlap them
+	if len(config.AllowedViews) > 0 && config.views()[0] == "" {
+		return nil, fmt.Errorf("EIP_ALLOWED_VIEWS requires EIP_VIEW or EIP_VIEWS to be set")
+	}
+	if len(config.ViewCIDRs) > 0 && config.views()[0] == "" {
+		return nil, fmt.Errorf("EIP_VIEW_CIDRS requires EIP_VIEW or EIP_VIEWS to be set")
+	}
+	router, err := newViewRouter(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid view routing: %w", err)
+	}
+
+	var nameFilter *regexp.Regexp
+	if config.NameRegEx != "" {
+		if nameFilter, err = regexp.Compile(config.NameRegEx); err != nil {
+			return nil, fmt.Errorf("invalid name filter '%s': %w", config.NameRegEx, err)
+		}
+	}
+
+	credentials, err := newCredentialStore(config)
+	if err != nil {
+		return nil, err
 	}
+	credentials.watch(config.CredentialsReloadInterval)
 
-	var ctx context.Context
-	if config.Token != "" && config.Secret != "" {
-		ctx = context.WithValue(context.Background(), eip.ContextEipApiTokenAuth, eip.EipApiTokenAuth{
-			Token:  config.Token,
-			Secret: config.Secret,
-		})
-	} else {
-		ctx = context.WithValue(context.Background(), eip.ContextBasicAuth, eip.BasicAuth{
-			UserName: config.Username,
-			Password: config.Password,
-		})
+	endpoints, err := newEndpointPool(config)
+	if err != nil {
+		return nil, err
 	}
+	active, _ := endpoints.current()
+	log.Infof("Using SOLIDserver endpoint %s (%d configured)", active, len(endpoints.endpoints))
 
-	ctx = context.WithValue(ctx, eip.ContextServerVariables, map[string]string{
-		"host": config.Host,
-		"port": strconv.Itoa(config.Port),
-	})
-	client := NewEfficientIPAPI(ctx, clientConfig, config)
+	client := NewEfficientIPAPI(clientConfig, config, credentials, endpoints)
 
 	return &Provider{
 		client:       &client,
 		domainFilter: domainFilter,
-		context:      ctx,
 		config:       config,
+		nameFilter:   nameFilter,
+		router:       router,
+		cache:        newRecordCache(config.ZoneCacheTTL, config.RecordCacheTTL),
 	}, nil
 }
@@ -0,0 +1,145 @@
+package soliddns
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// credentials are the authentication values of SOLIDserver calls.
+// Exactly one of basicAuth and tokenAuth is set.
+type credentials struct {
+	basicAuth *eip.BasicAuth       // Login/password authentication (if no token is set)
+	tokenAuth *eip.EipApiTokenAuth // API token authentication (optional)
+}
+
+// equal reports whether both credentials authenticate the same way
+func (c *credentials) equal(other *credentials) bool {
+	switch {
+	case c.tokenAuth != nil && other.tokenAuth != nil:
+		return *c.tokenAuth == *other.tokenAuth
+	case c.basicAuth != nil && other.basicAuth != nil:
+		return *c.basicAuth == *other.basicAuth
+	}
+	return false
+}
+
+// credentialStore holds the current credentials and swaps them atomically on reload
+type credentialStore struct {
+	current atomic.Pointer[credentials]
+	config  *EfficientIPConfig
+}
+
+// newCredentialStore loads the initial credentials.
+// Parameters:
+//   - config: Configuration with credentials from env vars and/or *_FILE paths
+//
+// Returns:
+//   - Store holding the loaded credentials
+//   - Error if credential files cannot be read or no credentials are configured
+func newCredentialStore(config *EfficientIPConfig) (*credentialStore, error) {
+	creds, err := loadCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+	store := &credentialStore{config: config}
+	store.current.Store(creds)
+	return store, nil
+}
+
+// load returns the current credentials
+func (s *credentialStore) load() *credentials {
+	return s.current.Load()
+}
+
+// reload reads the credential files again and swaps in changed credentials.
+// On failure the previous credentials stay in use.
+// Returns:
+//   - Whether the credentials changed
+//   - Error if the credentials could not be loaded
+func (s *credentialStore) reload() (bool, error) {
+	creds, err := loadCredentials(s.config)
+	if err != nil {
+		recordCredentialReload(err)
+		return false, err
+	}
+	if creds.equal(s.load()) {
+		return false, nil
+	}
+	s.current.Store(creds)
+	recordCredentialReload(nil)
+	return true, nil
+}
+
+// watch polls the credential files for changes until the process exits.
+// Mounted Kubernetes Secrets are swapped through symlinks, so contents are compared instead of
+// relying on file events. Nothing is watched if no *_FILE variable is set or the interval is 0.
+func (s *credentialStore) watch(interval time.Duration) {
+	if interval <= 0 || !s.config.hasCredentialFiles() {
+		return
+	}
+
+	log.Infof("Watching SOLIDserver credential files for changes every %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			changed, err := s.reload()
+			if err != nil {
+				log.Warnf("Failed to reload SOLIDserver credentials, keeping the current ones: %v", err)
+				continue
+			}
+			if changed {
+				log.Infof("Reloaded SOLIDserver credentials from files")
+			}
+		}
+	}()
+}
+
+// hasCredentialFiles reports whether any credential is read from a file
+func (config *EfficientIPConfig) hasCredentialFiles() bool {
+	return config.UsernameFile != "" || config.PasswordFile != "" || config.TokenFile != "" || config.SecretFile != ""
+}
+
+// loadCredentials builds the credentials from env vars, with *_FILE variants taking precedence.
+// The API token is used if both token and secret are set, login/password otherwise.
+// Parameters:
+//   - config: Configuration with credentials from env vars and/or *_FILE paths
+//
+// Returns:
+//   - Loaded credentials
+//   - Error if a file cannot be read or neither token/secret nor login/password are set
+func loadCredentials(config *EfficientIPConfig) (*credentials, error) {
+	username, password, token, secret := config.Username, config.Password, config.Token, config.Secret
+	for _, v := range []struct {
+		value *string
+		file  string
+	}{
+		{value: &username, file: config.UsernameFile},
+		{value: &password, file: config.PasswordFile},
+		{value: &token, file: config.TokenFile},
+		{value: &secret, file: config.SecretFile},
+	} {
+		if v.file == "" {
+			continue
+		}
+		content, err := os.ReadFile(v.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credential file: %w", err)
+		}
+		*v.value = strings.TrimSpace(string(content))
+	}
+
+	if token != "" && secret != "" {
+		return &credentials{tokenAuth: &eip.EipApiTokenAuth{Token: token, Secret: secret}}, nil
+	}
+	if username != "" && password != "" {
+		return &credentials{basicAuth: &eip.BasicAuth{UserName: username, Password: password}}, nil
+	}
+	return nil, fmt.Errorf("missing authentication credentials. Login/Password or access token/secret are required")
+}
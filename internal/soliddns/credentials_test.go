@@ -0,0 +1,101 @@
+package soliddns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	secretFile := filepath.Join(dir, "secret")
+	passwordFile := filepath.Join(dir, "password")
+	for file, content := range map[string]string{tokenFile: "file-token\n", secretFile: "file-secret\n", passwordFile: "file-password"} {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		config   EfficientIPConfig
+		expected credentials
+		err      bool
+	}{
+		{
+			name:     "basic auth from env",
+			config:   EfficientIPConfig{Username: "admin", Password: "env-password"},
+			expected: credentials{basicAuth: &eip.BasicAuth{UserName: "admin", Password: "env-password"}},
+		},
+		{
+			name:     "password file overrides env",
+			config:   EfficientIPConfig{Username: "admin", Password: "env-password", PasswordFile: passwordFile},
+			expected: credentials{basicAuth: &eip.BasicAuth{UserName: "admin", Password: "file-password"}},
+		},
+		{
+			name:     "token from files",
+			config:   EfficientIPConfig{Username: "admin", TokenFile: tokenFile, SecretFile: secretFile},
+			expected: credentials{tokenAuth: &eip.EipApiTokenAuth{Token: "file-token", Secret: "file-secret"}},
+		},
+		{
+			name:   "missing file",
+			config: EfficientIPConfig{Username: "admin", PasswordFile: filepath.Join(dir, "missing")},
+			err:    true,
+		},
+		{
+			name:   "no credentials",
+			config: EfficientIPConfig{Username: "admin"},
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		actual, err := loadCredentials(&tc.config)
+		if (err != nil) != tc.err {
+			t.Errorf("%s: expected error=%t, got %v", tc.name, tc.err, err)
+			continue
+		}
+		if err == nil && !actual.equal(&tc.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestCredentialStoreReload(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("first"), 0o600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+
+	store, err := newCredentialStore(&EfficientIPConfig{Username: "admin", PasswordFile: passwordFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if changed, err := store.reload(); changed || err != nil {
+		t.Errorf("expected unchanged credentials, got changed=%t (%v)", changed, err)
+	}
+
+	if err := os.WriteFile(passwordFile, []byte("second"), 0o600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+	if changed, err := store.reload(); !changed || err != nil {
+		t.Errorf("expected changed credentials, got changed=%t (%v)", changed, err)
+	}
+	if password := store.load().basicAuth.Password; password != "second" {
+		t.Errorf("expected rotated password, got %s", password)
+	}
+
+	if err := os.Remove(passwordFile); err != nil {
+		t.Fatalf("failed to remove password file: %v", err)
+	}
+	if _, err := store.reload(); err == nil {
+		t.Errorf("expected an error for a missing password file")
+	}
+	if password := store.load().basicAuth.Password; password != "second" {
+		t.Errorf("expected previous credentials to stay in use, got %s", password)
+	}
+}
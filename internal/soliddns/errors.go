@@ -0,0 +1,221 @@
+package soliddns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+)
+
+// Error classes of SOLIDserver API errors, matched with errors.Is
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("conflict")
+	ErrAuthFailed       = errors.New("authentication failed")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrValidation       = errors.New("validation failed")
+	ErrServerBusy       = errors.New("server busy")
+)
+
+// Fragments of SOLIDserver error messages identifying an error class regardless of the HTTP status
+var errorMessageClasses = []struct {
+	class     error
+	fragments []string
+}{
+	{class: ErrConflict, fragments: []string{"already exist", "duplicate"}},
+	{class: ErrNotFound, fragments: []string{"not found", "not exist", "doesn't exist", "no such"}},
+	{class: ErrAuthFailed, fragments: []string{"authentication", "invalid credentials", "bad login"}},
+	{class: ErrPermissionDenied, fragments: []string{"permission", "forbidden", "access denied"}},
+	{class: ErrServerBusy, fragments: []string{"busy", "too many requests", "try again later"}},
+}
+
+// Patterns telling errors about a missing record from errors about the zone, view or smart it was looked up in
+var (
+	recordMessagePattern = regexp.MustCompile(`\b(rrs?|records?)\b`)
+	scopeNotFoundPattern = regexp.MustCompile(`\b(zone|view|server|smart|space)s?\b[^,;]*\b(not found|not exist|doesn't exist)|\bno such (zone|view|server|smart|space)\b`)
+)
+
+// maxErrorBodyLength bounds the raw response body kept in errors without a JSON payload
+const maxErrorBodyLength = 256
+
+// APIError is an error response of the SOLIDserver API.
+// It unwraps to one of the error classes (ErrNotFound, ErrConflict, ...) when it could be classified.
+type APIError struct {
+	StatusCode int    // HTTP status of the response (0 if unknown)
+	Errno      string // SOLIDserver error number
+	Errmsg     string // SOLIDserver error message
+	Parameter  string // Parameter the error refers to (optional)
+	class      error
+}
+
+// Error formats the SOLIDserver error with its status, number and parameter
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("SOLIDserver returned status %d", e.StatusCode)
+	if e.class != nil {
+		msg += " (" + e.class.Error() + ")"
+	}
+	if e.Errno != "" {
+		msg += ", errno " + e.Errno
+	}
+	if e.Errmsg != "" {
+		msg += ": " + e.Errmsg
+	}
+	if e.Parameter != "" {
+		msg += " [parameter " + e.Parameter + "]"
+	}
+	return msg
+}
+
+// Unwrap returns the error class so callers can use errors.Is
+func (e *APIError) Unwrap() error {
+	return e.class
+}
+
+// errorPayload is the JSON error body of a SOLIDserver response
+type errorPayload struct {
+	Errno     flexString `json:"errno"`
+	Errmsg    flexString `json:"errmsg"`
+	Parameter flexString `json:"parameter"`
+}
+
+// flexString decodes JSON strings as is and any other JSON value as its raw text
+type flexString string
+
+// UnmarshalJSON accepts strings, numbers and objects
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+	if string(data) != "null" {
+		*f = flexString(data)
+	}
+	return nil
+}
+
+// newAPIError builds a typed error from a failed SOLIDserver call.
+// Transport errors are returned unchanged; responses with an error status become an APIError.
+// Parameters:
+//   - resp: HTTP response of the call (may be nil)
+//   - err: Error returned by the API client (may be nil for error statuses)
+//
+// Returns:
+//   - Typed error, or nil if the call succeeded
+func newAPIError(resp *http.Response, err error) error {
+	var body []byte
+	var apiErr *eip.GenericOpenAPIError
+	var apiErrValue eip.GenericOpenAPIError
+	switch {
+	case errors.As(err, &apiErr):
+		body = apiErr.Body()
+	case errors.As(err, &apiErrValue):
+		body = apiErrValue.Body()
+	case resp == nil || resp.StatusCode < 400:
+		// Transport errors and successful calls
+		return err
+	}
+
+	result := &APIError{}
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	if payload, ok := parseErrorPayload(body); ok {
+		result.Errno = string(payload.Errno)
+		result.Errmsg = string(payload.Errmsg)
+		result.Parameter = string(payload.Parameter)
+	} else if text := strings.TrimSpace(string(body)); text != "" {
+		if len(text) > maxErrorBodyLength {
+			text = text[:maxErrorBodyLength] + "..."
+		}
+		result.Errmsg = text
+	} else if err != nil {
+		result.Errmsg = err.Error()
+	}
+	result.class = classifyAPIError(result.StatusCode, result.Errmsg)
+	return result
+}
+
+// parseErrorPayload decodes the SOLIDserver error body, either a single object or a list of errors.
+// Returns false if the body holds no error fields.
+func parseErrorPayload(body []byte) (errorPayload, bool) {
+	body = bytes.TrimSpace(body)
+
+	var payloads []errorPayload
+	if bytes.HasPrefix(body, []byte("[")) {
+		if err := json.Unmarshal(body, &payloads); err != nil {
+			return errorPayload{}, false
+		}
+	} else {
+		var payload errorPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return errorPayload{}, false
+		}
+		payloads = append(payloads, payload)
+	}
+
+	for _, payload := range payloads {
+		if payload.Errno != "" || payload.Errmsg != "" {
+			return payload, true
+		}
+	}
+	return errorPayload{}, false
+}
+
+// classifyAPIError maps a SOLIDserver error to its error class.
+// The message wins over the HTTP status as SOLIDserver reports most errors as 400.
+func classifyAPIError(statusCode int, errmsg string) error {
+	message := strings.ToLower(errmsg)
+	for _, c := range errorMessageClasses {
+		for _, fragment := range c.fragments {
+			if strings.Contains(message, fragment) {
+				return c.class
+			}
+		}
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return ErrAuthFailed
+	case http.StatusForbidden:
+		return ErrPermissionDenied
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return ErrServerBusy
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrValidation
+	}
+	return nil
+}
+
+// isRecordNotFound reports whether a SOLIDserver error says that the record itself does not exist.
+// Not-found errors about the zone, view or smart the record was looked up in point at a
+// misconfiguration rather than at an already deleted record and are not matched.
+// Parameters:
+//   - err: Error of a SOLIDserver call
+//
+// Returns:
+//   - True if the error refers to a missing record
+func isRecordNotFound(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrNotFound) {
+		return false
+	}
+
+	if apiErr.Parameter != "" {
+		return strings.HasPrefix(strings.ToLower(apiErr.Parameter), "rr_")
+	}
+	message := strings.ToLower(apiErr.Errmsg)
+	if scopeNotFoundPattern.MatchString(message) {
+		return false
+	}
+	return recordMessagePattern.MatchString(message)
+}
@@ -0,0 +1,113 @@
+package soliddns
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseErrorPayload(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected errorPayload
+		ok       bool
+	}{
+		{
+			name:     "object",
+			body:     `{"success":false,"errno":"2010","errmsg":"Record already exists","parameter":"rr_name"}`,
+			expected: errorPayload{Errno: "2010", Errmsg: "Record already exists", Parameter: "rr_name"},
+			ok:       true,
+		},
+		{
+			name:     "list with numeric errno",
+			body:     `[{"errno":1003,"errmsg":"Invalid TTL","parameter":"rr_ttl"}]`,
+			expected: errorPayload{Errno: "1003", Errmsg: "Invalid TTL", Parameter: "rr_ttl"},
+			ok:       true,
+		},
+		{name: "no error fields", body: `{"success":false}`, ok: false},
+		{name: "not json", body: `<html>Bad Gateway</html>`, ok: false},
+		{name: "empty", body: ``, ok: false},
+	}
+
+	for _, tc := range testCases {
+		actual, ok := parseErrorPayload([]byte(tc.body))
+		if ok != tc.ok {
+			t.Errorf("%s: expected ok=%t, got %t", tc.name, tc.ok, ok)
+			continue
+		}
+		if ok && actual != tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestClassifyAPIError(t *testing.T) {
+	testCases := []struct {
+		status   int
+		errmsg   string
+		expected error
+	}{
+		{status: http.StatusBadRequest, errmsg: "Record already exists", expected: ErrConflict},
+		{status: http.StatusBadRequest, errmsg: "The RR does not exist", expected: ErrNotFound},
+		{status: http.StatusBadRequest, errmsg: "Invalid TTL value", expected: ErrValidation},
+		{status: http.StatusUnauthorized, expected: ErrAuthFailed},
+		{status: http.StatusForbidden, expected: ErrPermissionDenied},
+		{status: http.StatusNotFound, expected: ErrNotFound},
+		{status: http.StatusConflict, expected: ErrConflict},
+		{status: http.StatusServiceUnavailable, expected: ErrServerBusy},
+		{status: http.StatusInternalServerError, errmsg: "Server is busy, try again later", expected: ErrServerBusy},
+		{status: http.StatusInternalServerError, errmsg: "Internal error", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		if actual := classifyAPIError(tc.status, tc.errmsg); actual != tc.expected {
+			t.Errorf("classifyAPIError(%d, %q): expected %v, got %v", tc.status, tc.errmsg, tc.expected, actual)
+		}
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	transport := errors.New("connection refused")
+	if err := newAPIError(nil, transport); err != transport {
+		t.Errorf("expected transport errors to be returned unchanged, got %v", err)
+	}
+	if err := newAPIError(&http.Response{StatusCode: http.StatusOK}, nil); err != nil {
+		t.Errorf("expected no error for successful calls, got %v", err)
+	}
+
+	err := newAPIError(&http.Response{StatusCode: http.StatusNotFound}, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected APIError with status 404, got %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected APIError to unwrap to ErrNotFound, got %v", err)
+	}
+}
+
+func TestIsRecordNotFound(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "record parameter", err: &APIError{Errmsg: "Object not found", Parameter: "rr_id", class: ErrNotFound}, expected: true},
+		{name: "record message", err: &APIError{Errmsg: "The RR does not exist", class: ErrNotFound}, expected: true},
+		{name: "record message naming its zone", err: &APIError{Errmsg: "Record not found in zone example.com", class: ErrNotFound}, expected: true},
+		{name: "view parameter", err: &APIError{Errmsg: "Not found", Parameter: "view_name", class: ErrNotFound}, expected: false},
+		{name: "view message", err: &APIError{Errmsg: "View not found", class: ErrNotFound}, expected: false},
+		{name: "server message", err: &APIError{Errmsg: "DNS server does not exist", class: ErrNotFound}, expected: false},
+		{name: "zone message", err: &APIError{Errmsg: "No such zone for record www.example.com", class: ErrNotFound}, expected: false},
+		{name: "bare 404", err: &APIError{StatusCode: http.StatusNotFound, class: ErrNotFound}, expected: false},
+		{name: "other class", err: &APIError{Errmsg: "Record already exists", class: ErrConflict}, expected: false},
+		{name: "transport error", err: errors.New("record not found"), expected: false},
+		{name: "no error", expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := isRecordNotFound(tc.err); actual != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.expected, actual)
+		}
+	}
+}
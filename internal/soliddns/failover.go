@@ -0,0 +1,199 @@
+package soliddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// apiEndpoint is a SOLIDserver management node
+type apiEndpoint struct {
+	host string
+	port string
+}
+
+// String returns the endpoint as host:port
+func (a apiEndpoint) String() string {
+	return net.JoinHostPort(a.host, a.port)
+}
+
+// EndpointStatus reports the health of a SOLIDserver management node
+type EndpointStatus struct {
+	Address     string     `json:"address"`
+	Active      bool       `json:"active"`
+	Failures    int        `json:"failures"`
+	LastFailure *time.Time `json:"lastFailure,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// endpointPool tracks the health of the SOLIDserver nodes and the active one.
+// All calls go to the active node until it fails; the pool then switches to the
+// next node, preferring nodes that did not fail recently. Once an earlier node has
+// not failed for the failback delay, calls go back to it.
+type endpointPool struct {
+	mu               sync.Mutex
+	endpoints        []apiEndpoint
+	status           []EndpointStatus
+	active           int
+	failoverStatuses map[int]bool
+	failbackDelay    time.Duration // Time without failure before an earlier node is used again (0 disables failback)
+	now              func() time.Time
+}
+
+// newEndpointPool parses the configured nodes.
+// EIP_HOSTS lists host or host:port entries; without it EIP_HOST is the only node.
+// Parameters:
+//   - config: Configuration with the hosts, default port, failover statuses and failback delay
+//
+// Returns:
+//   - Pool with the first node active
+//   - Error if an entry is invalid
+func newEndpointPool(config *EfficientIPConfig) (*endpointPool, error) {
+	hosts := config.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{config.Host}
+	}
+
+	pool := &endpointPool{
+		failoverStatuses: make(map[int]bool, len(config.FailoverStatusCodes)),
+		failbackDelay:    config.FailbackDelay,
+		now:              time.Now,
+	}
+	for _, entry := range hosts {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		endpoint, err := parseAPIEndpoint(entry, config.Port)
+		if err != nil {
+			return nil, err
+		}
+		pool.endpoints = append(pool.endpoints, endpoint)
+		pool.status = append(pool.status, EndpointStatus{Address: endpoint.String()})
+	}
+	if len(pool.endpoints) == 0 {
+		return nil, fmt.Errorf("no SOLIDserver host configured")
+	}
+	for _, code := range config.FailoverStatusCodes {
+		pool.failoverStatuses[code] = true
+	}
+	return pool, nil
+}
+
+// parseAPIEndpoint parses a host, host:port or [ipv6]:port entry
+func parseAPIEndpoint(entry string, defaultPort int) (apiEndpoint, error) {
+	host, port, err := net.SplitHostPort(entry)
+	if err != nil {
+		// No port given
+		host, port = strings.Trim(entry, "[]"), strconv.Itoa(defaultPort)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return apiEndpoint{}, fmt.Errorf("invalid SOLIDserver host '%s': bad port", entry)
+	}
+	if host == "" {
+		return apiEndpoint{}, fmt.Errorf("invalid SOLIDserver host '%s': missing host", entry)
+	}
+	return apiEndpoint{host: host, port: port}, nil
+}
+
+// current returns the active node and its index, failing back to an earlier node first if it is due
+func (p *endpointPool) current() (apiEndpoint, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failback()
+	return p.endpoints[p.active], p.active
+}
+
+// failback switches to the first node before the active one that has not failed for the failback delay.
+// A node that is still down fails over again on its next call. Must be called with the lock held.
+func (p *endpointPool) failback() {
+	if p.failbackDelay <= 0 {
+		return
+	}
+	now := p.now()
+	for i := 0; i < p.active; i++ {
+		if last := p.status[i].LastFailure; last == nil || now.Sub(*last) >= p.failbackDelay {
+			log.Infof("SOLIDserver endpoint %s has not failed for %s, failing back from %s", p.endpoints[i], p.failbackDelay, p.endpoints[p.active])
+			p.active = i
+			return
+		}
+	}
+}
+
+// shouldFailover reports whether a failed call on a node warrants switching to another one.
+// Calls abandoned by the caller say nothing about the node.
+func (p *endpointPool) shouldFailover(ctx context.Context, resp *http.Response, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if resp == nil {
+		var apiErr *APIError
+		return !errors.As(err, &apiErr)
+	}
+	return p.failoverStatuses[resp.StatusCode]
+}
+
+// failover records the failure of a node and, if it is still active, switches to the next one.
+// Concurrent calls failing on the same node only switch once.
+// Returns:
+//   - Whether another node is now active
+func (p *endpointPool) failover(index int, err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	status := &p.status[index]
+	status.Failures++
+	status.LastFailure = &now
+	status.LastError = err.Error()
+
+	if len(p.endpoints) == 1 {
+		return false
+	}
+	if index != p.active {
+		return true
+	}
+
+	// Prefer the next node that failed longest ago (or never)
+	next := (index + 1) % len(p.endpoints)
+	for i := 1; i < len(p.endpoints); i++ {
+		candidate := (index + i) % len(p.endpoints)
+		if p.status[candidate].LastFailure == nil {
+			next = candidate
+			break
+		}
+		if p.status[candidate].LastFailure.Before(*p.status[next].LastFailure) {
+			next = candidate
+		}
+	}
+
+	p.active = next
+	log.Warnf("SOLIDserver endpoint %s failed (%v), failing over to %s", p.endpoints[index], err, p.endpoints[next])
+	return true
+}
+
+// succeeded records a successful call on a node
+func (p *endpointPool) succeeded(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status[index].Failures = 0
+}
+
+// statuses returns the health of all nodes
+func (p *endpointPool) statuses() (active string, endpoints []EndpointStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	endpoints = make([]EndpointStatus, len(p.status))
+	copy(endpoints, p.status)
+	endpoints[p.active].Active = true
+	return p.endpoints[p.active].String(), endpoints
+}
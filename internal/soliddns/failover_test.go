@@ -0,0 +1,208 @@
+package soliddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+)
+
+func TestNewEndpointPool(t *testing.T) {
+	testCases := []struct {
+		name     string
+		host     string
+		hosts    []string
+		expected []string
+		err      bool
+	}{
+		{name: "single host", host: "sds.example.com", expected: []string{"sds.example.com:443"}},
+		{name: "host list", host: "ignored", hosts: []string{"sds1.example.com", " sds2.example.com:8443 "}, expected: []string{"sds1.example.com:443", "sds2.example.com:8443"}},
+		{name: "ipv6", hosts: []string{"[2001:db8::1]:8443", "[2001:db8::2]"}, expected: []string{"[2001:db8::1]:8443", "[2001:db8::2]:443"}},
+		{name: "bad port", hosts: []string{"sds1.example.com:https"}, err: true},
+		{name: "empty", hosts: []string{" ", ""}, err: true},
+	}
+
+	for _, tc := range testCases {
+		pool, err := newEndpointPool(&EfficientIPConfig{Host: tc.host, Port: 443, Hosts: tc.hosts})
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if len(pool.endpoints) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, pool.endpoints)
+			continue
+		}
+		for i, endpoint := range pool.endpoints {
+			if endpoint.String() != tc.expected[i] {
+				t.Errorf("%s: expected %s, got %s", tc.name, tc.expected[i], endpoint)
+			}
+		}
+	}
+}
+
+func TestEndpointPoolShouldFailover(t *testing.T) {
+	pool := &endpointPool{failoverStatuses: map[int]bool{http.StatusBadGateway: true, http.StatusServiceUnavailable: true}}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		status   int
+		err      error
+		expected bool
+	}{
+		{name: "success", ctx: context.Background(), expected: false},
+		{name: "connection refused", ctx: context.Background(), err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: true},
+		{name: "request timeout", ctx: context.Background(), err: context.DeadlineExceeded, expected: true},
+		{name: "caller gave up", ctx: cancelled, err: context.Canceled, expected: false},
+		{name: "bad gateway", ctx: context.Background(), status: http.StatusBadGateway, expected: true},
+		{name: "internal server error", ctx: context.Background(), status: http.StatusInternalServerError, expected: false},
+		{name: "not found", ctx: context.Background(), status: http.StatusNotFound, expected: false},
+	}
+
+	for _, tc := range testCases {
+		var resp *http.Response
+		err := tc.err
+		if tc.status != 0 {
+			resp = &http.Response{StatusCode: tc.status}
+			err = newAPIError(resp, nil)
+		}
+		if actual := pool.shouldFailover(tc.ctx, resp, err); actual != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestEndpointPoolFailover(t *testing.T) {
+	pool, err := newEndpointPool(&EfficientIPConfig{Port: 443, Hosts: []string{"sds1", "sds2", "sds3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	if !pool.failover(0, failure) {
+		t.Fatal("expected a failover from the first node")
+	}
+	if _, active := pool.current(); active != 1 {
+		t.Fatalf("expected the second node to be active, got %d", active)
+	}
+
+	// A late failure of the old node must not switch again
+	pool.failover(0, failure)
+	if _, active := pool.current(); active != 1 {
+		t.Fatalf("expected the second node to stay active, got %d", active)
+	}
+
+	// The third node never failed and is preferred over the first one
+	now = now.Add(time.Minute)
+	pool.failover(1, failure)
+	if _, active := pool.current(); active != 2 {
+		t.Fatalf("expected the third node to be active, got %d", active)
+	}
+
+	// All nodes failed, the one that failed longest ago is next
+	now = now.Add(time.Minute)
+	pool.failover(2, failure)
+	if _, active := pool.current(); active != 0 {
+		t.Fatalf("expected the first node to be active, got %d", active)
+	}
+
+	pool.succeeded(0)
+	active, statuses := pool.statuses()
+	if active != "sds1:443" || !statuses[0].Active || statuses[0].Failures != 0 || statuses[1].Failures != 1 || statuses[1].LastError == "" {
+		t.Errorf("unexpected statuses: %s %+v", active, statuses)
+	}
+}
+
+func TestEndpointPoolFailback(t *testing.T) {
+	pool, err := newEndpointPool(&EfficientIPConfig{Port: 443, Hosts: []string{"sds1", "sds2", "sds3"}, FailbackDelay: 5 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	pool.failover(0, failure)
+	now = now.Add(time.Minute)
+	pool.failover(1, failure)
+	if _, active := pool.current(); active != 2 {
+		t.Fatalf("expected the third node to be active, got %d", active)
+	}
+
+	// Nodes are only used again once they have not failed for the delay, the first one first
+	now = now.Add(3 * time.Minute)
+	if _, active := pool.current(); active != 2 {
+		t.Fatalf("expected the third node to stay active during the delay, got %d", active)
+	}
+	now = now.Add(time.Minute)
+	if _, active := pool.current(); active != 0 {
+		t.Fatalf("expected a failback to the first node, got %d", active)
+	}
+
+	// A node still down fails over again and is not used before the next delay
+	pool.failover(0, failure)
+	if _, active := pool.current(); active != 2 {
+		t.Fatalf("expected the third node to be active, got %d", active)
+	}
+	now = now.Add(time.Minute)
+	if _, active := pool.current(); active != 1 {
+		t.Fatalf("expected a failback to the second node, got %d", active)
+	}
+
+	pool.failbackDelay = 0
+	now = now.Add(time.Hour)
+	if _, active := pool.current(); active != 1 {
+		t.Errorf("expected no failback when disabled, got %d", active)
+	}
+}
+
+func TestDoFailsOverToNextEndpoint(t *testing.T) {
+	pool, err := newEndpointPool(&EfficientIPConfig{Port: 443, Hosts: []string{"sds1", "sds2"}, FailoverStatusCodes: []int{http.StatusServiceUnavailable}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &credentialStore{}
+	store.current.Store(&credentials{basicAuth: &eip.BasicAuth{UserName: "user", Password: "pass"}})
+
+	api := EfficientIPAPI{
+		credentials:  store,
+		endpoints:    pool,
+		retry:        retryPolicy{maxAttempts: 3, baseDelay: time.Hour, maxDelay: time.Hour},
+		breaker:      newCircuitBreaker(5, time.Minute),
+		readLimiter:  newRateLimiter(0, 1),
+		writeLimiter: newRateLimiter(0, 1),
+	}
+
+	var hosts []string
+	err = api.do(context.Background(), callRead, func(ctx context.Context) (*http.Response, error) {
+		host := ctx.Value(eip.ContextServerVariables).(map[string]string)["host"]
+		hosts = append(hosts, host)
+		if host == "sds1" {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable}, errors.New("503 Service Unavailable")
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hosts) != 2 || hosts[0] != "sds1" || hosts[1] != "sds2" {
+		t.Errorf("expected a call to sds1 then sds2 without backoff, got %v", hosts)
+	}
+	if status := api.Health(); status.ActiveEndpoint != "sds2:443" {
+		t.Errorf("expected sds2:443 to be active, got %+v", status)
+	}
+}
@@ -0,0 +1,125 @@
+package soliddns
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// regexToLikeClause translates a regular expression into an equivalent or broader
+// SOLIDserver WHERE condition using LIKE patterns, so that filtering can happen
+// server-side. Top-level alternations become OR-ed patterns; any construct that has
+// no LIKE equivalent is widened to a wildcard, which is why results must still be
+// filtered client-side with the original expression.
+// Parameters:
+//   - column: Column to match against (e.g. "rr_full_name")
+//   - expr: Regular expression with MatchString semantics (unanchored)
+//
+// Returns:
+//   - WHERE condition, e.g. "(rr_full_name LIKE '%.example.com')"
+//   - False if the expression cannot narrow the listing server-side
+func regexToLikeClause(column, expr string) (string, bool) {
+	if expr == "" {
+		return "", false
+	}
+
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+
+	branches := []*syntax.Regexp{re}
+	if re.Op == syntax.OpAlternate {
+		branches = re.Sub
+	}
+
+	conditions := make([]string, 0, len(branches))
+	for _, branch := range branches {
+		pattern, ok := regexToLikePattern(branch)
+		if !ok || pattern == "%" {
+			return "", false
+		}
+		conditions = append(conditions, fmt.Sprintf("%s LIKE '%s'", column, pattern))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", true
+}
+
+// regexToLikePattern converts a single regular expression branch into a LIKE pattern
+func regexToLikePattern(re *syntax.Regexp) (string, bool) {
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+
+	parts := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		parts = re.Sub
+	}
+
+	var sb strings.Builder
+	anchoredStart, anchoredEnd := false, false
+	for i, part := range parts {
+		switch part.Op {
+		case syntax.OpBeginText, syntax.OpBeginLine:
+			if i != 0 {
+				return "", false
+			}
+			anchoredStart = true
+		case syntax.OpEndText, syntax.OpEndLine:
+			if i != len(parts)-1 {
+				return "", false
+			}
+			anchoredEnd = true
+		case syntax.OpLiteral:
+			if part.Flags&syntax.FoldCase != 0 {
+				return "", false
+			}
+			for _, r := range part.Rune {
+				switch r {
+				case '\'', '\\':
+					return "", false
+				case '%', '_':
+					sb.WriteByte('_')
+				default:
+					sb.WriteRune(r)
+				}
+			}
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpCharClass:
+			sb.WriteByte('_')
+		default:
+			sb.WriteByte('%')
+		}
+	}
+
+	pattern := sb.String()
+	if !anchoredStart {
+		pattern = "%" + pattern
+	}
+	if !anchoredEnd {
+		pattern += "%"
+	}
+	for strings.Contains(pattern, "%%") {
+		pattern = strings.ReplaceAll(pattern, "%%", "%")
+	}
+	return pattern, true
+}
+
+// whereEquals builds a SOLIDserver WHERE condition matching a column to a literal value.
+// SOLIDserver literals have no escape for quotes, so values holding quotes or backslashes
+// are rejected instead of being allowed to alter the clause.
+// Parameters:
+//   - column: Column to match against (e.g. "rr_full_name")
+//   - value: Literal value of the column
+//
+// Returns:
+//   - WHERE condition, e.g. "rr_full_name='www.example.com'"
+//   - False if the value cannot be quoted safely
+func whereEquals(column, value string) (string, bool) {
+	if strings.ContainsAny(value, `'\`) {
+		return "", false
+	}
+	return fmt.Sprintf("%s='%s'", column, value), true
+}
@@ -0,0 +1,33 @@
+package soliddns
+
+import "testing"
+
+func TestRegexToLikeClause(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected string
+		ok       bool
+	}{
+		{expr: "", ok: false},
+		{expr: ".*", ok: false},
+		{expr: "[", ok: false},
+		{expr: "(?i)example", ok: false},
+		{expr: "it's", ok: false},
+		{expr: "example.com", expected: "(rr_full_name LIKE '%example_com%')", ok: true},
+		{expr: "^www\\.example\\.com$", expected: "(rr_full_name LIKE 'www.example.com')", ok: true},
+		{expr: "^api-[0-9]+\\.example\\.com$", expected: "(rr_full_name LIKE 'api-%.example.com')", ok: true},
+		{expr: "(my-project.org-hq|.us.cloud)", expected: "(rr_full_name LIKE '%my-project_org-hq%' OR rr_full_name LIKE '%_us_cloud%')", ok: true},
+		{expr: "_acme-challenge\\.", expected: "(rr_full_name LIKE '%_acme-challenge.%')", ok: true},
+	}
+
+	for _, tc := range testCases {
+		actual, ok := regexToLikeClause("rr_full_name", tc.expr)
+		if ok != tc.ok {
+			t.Errorf("regexToLikeClause(%q): expected ok=%t, got %t (%s)", tc.expr, tc.ok, ok, actual)
+			continue
+		}
+		if ok && actual != tc.expected {
+			t.Errorf("regexToLikeClause(%q): expected %s, got %s", tc.expr, tc.expected, actual)
+		}
+	}
+}
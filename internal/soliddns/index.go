@@ -0,0 +1,122 @@
+package soliddns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// recordIndex remembers where listed records live and their SOLIDserver rr_id so
+// that later changes can target the exact record they were read from.
+// Records of the same name live in every DNS smart and view, so all keys are scoped by both.
+// It is safe for concurrent use.
+type recordIndex struct {
+	mu    sync.RWMutex
+	zones map[string]string             // Zone name by scope, record name and type
+	ids   map[string]map[string][]int32 // rr_ids by scope and zone, then by record name, type and target
+}
+
+// newRecordIndex creates an empty record index
+func newRecordIndex() *recordIndex {
+	return &recordIndex{
+		zones: make(map[string]string),
+		ids:   make(map[string]map[string][]int32),
+	}
+}
+
+// setRecord records the zone and rr_id of a record listed from a scope (0 if the rr_id is unknown)
+func (i *recordIndex) setRecord(scope recordScope, zone, name, recordType, target string, id int32) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.zones[scopeKey(scope, recordKey(name, recordType))] = zone
+	if id == 0 {
+		return
+	}
+
+	ids, found := i.ids[scopeKey(scope, zone)]
+	if !found {
+		ids = make(map[string][]int32)
+		i.ids[scopeKey(scope, zone)] = ids
+	}
+	key := targetKey(name, recordType, target)
+	ids[key] = append(ids[key], id)
+}
+
+// zone returns the zone a record was listed from in a scope
+func (i *recordIndex) zone(scope recordScope, name, recordType string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	zone, found := i.zones[scopeKey(scope, recordKey(name, recordType))]
+	return zone, found
+}
+
+// recordIDs returns the rr_ids listed for a record target in a scope, including duplicates
+func (i *recordIndex) recordIDs(scope recordScope, name, recordType, target string) []int32 {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	zone, found := i.zones[scopeKey(scope, recordKey(name, recordType))]
+	if !found {
+		return nil
+	}
+	return i.ids[scopeKey(scope, zone)][targetKey(name, recordType, target)]
+}
+
+// forget removes the rr_ids of a record target of a scope that no longer exists
+func (i *recordIndex) forget(scope recordScope, name, recordType, target string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if zone, found := i.zones[scopeKey(scope, recordKey(name, recordType))]; found {
+		delete(i.ids[scopeKey(scope, zone)], targetKey(name, recordType, target))
+	}
+}
+
+// resetZone forgets all records listed from a zone of a scope before it is listed again
+func (i *recordIndex) resetZone(scope recordScope, zone string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	prefix := scopeKey(scope, "")
+	for key, z := range i.zones {
+		if z == zone && strings.HasPrefix(key, prefix) {
+			delete(i.zones, key)
+		}
+	}
+	delete(i.ids, scopeKey(scope, zone))
+}
+
+// parseRecordID converts an rr_id as listed by SOLIDserver to the numeric id taken by record calls.
+// Parameters:
+//   - id: rr_id of the record
+//
+// Returns:
+//   - Numeric rr_id
+//   - Error if the rr_id is not a positive 32-bit number
+func parseRecordID(id string) (int32, error) {
+	value, err := strconv.ParseInt(id, 10, 32)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid rr_id '%s'", id)
+	}
+	return int32(value), nil
+}
+
+// scopeKey scopes an index key to a DNS smart and view
+func scopeKey(scope recordScope, key string) string {
+	return scope.key() + "|" + key
+}
+
+// recordKey returns the case-insensitive index key of a record
+func recordKey(name, recordType string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + ":" + recordType
+}
+
+// targetKey returns the index key of a record target.
+// Targets are compared case-insensitively except for TXT records.
+func targetKey(name, recordType, target string) string {
+	target = normalizeTarget(recordType, target)
+	if recordType != endpoint.RecordTypeTXT {
+		target = strings.ToLower(target)
+	}
+	return recordKey(name, recordType) + ":" + target
+}
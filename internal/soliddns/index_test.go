@@ -0,0 +1,126 @@
+package soliddns
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestRecordIndexScopes(t *testing.T) {
+	index := newRecordIndex()
+	internal := recordScope{smart: "smart", view: "internal"}
+	external := recordScope{smart: "smart", view: "external"}
+
+	index.setRecord(internal, "example.com", "www.example.com", endpoint.RecordTypeA, "10.0.0.1", 1)
+	index.setRecord(external, "www.example.com", "www.example.com", endpoint.RecordTypeA, "192.0.2.1", 2)
+
+	if zone, found := index.zone(internal, "WWW.example.com.", endpoint.RecordTypeA); !found || zone != "example.com" {
+		t.Errorf("expected zone example.com in the internal view, got %q (found=%t)", zone, found)
+	}
+	if zone, found := index.zone(external, "www.example.com", endpoint.RecordTypeA); !found || zone != "www.example.com" {
+		t.Errorf("expected zone www.example.com in the external view, got %q (found=%t)", zone, found)
+	}
+	if _, found := index.zone(recordScope{smart: "other", view: "internal"}, "www.example.com", endpoint.RecordTypeA); found {
+		t.Error("expected no zone in a scope that was not listed")
+	}
+	if _, found := index.zone(internal, "www.example.com", endpoint.RecordTypeAAAA); found {
+		t.Error("expected no zone for a record type that was not listed")
+	}
+
+	if ids := index.recordIDs(internal, "www.example.com", endpoint.RecordTypeA, "192.0.2.1"); ids != nil {
+		t.Errorf("expected no rr_id for a target of another view, got %v", ids)
+	}
+	if ids := index.recordIDs(external, "www.example.com", endpoint.RecordTypeA, "192.0.2.1"); !reflect.DeepEqual(ids, []int32{2}) {
+		t.Errorf("expected rr_id 2 in the external view, got %v", ids)
+	}
+}
+
+func TestRecordIndexDuplicatesAndForget(t *testing.T) {
+	index := newRecordIndex()
+	scope := recordScope{smart: "smart"}
+
+	index.setRecord(scope, "example.com", "example.com", endpoint.RecordTypeMX, "10 mail.example.com.", 3)
+	index.setRecord(scope, "example.com", "example.com", endpoint.RecordTypeMX, "10 MAIL.example.com", 4)
+	index.setRecord(scope, "example.com", "example.com", endpoint.RecordTypeMX, "20 mx.example.com", 5)
+	index.setRecord(scope, "example.com", "example.com", endpoint.RecordTypeMX, "30 unknown.example.com", 0)
+
+	if ids := index.recordIDs(scope, "example.com", endpoint.RecordTypeMX, "10 mail.example.com"); !reflect.DeepEqual(ids, []int32{3, 4}) {
+		t.Errorf("expected duplicate rr_ids [3 4], got %v", ids)
+	}
+	if ids := index.recordIDs(scope, "example.com", endpoint.RecordTypeMX, "30 unknown.example.com"); ids != nil {
+		t.Errorf("expected no rr_id for a record listed without one, got %v", ids)
+	}
+
+	index.forget(scope, "example.com", endpoint.RecordTypeMX, "10 mail.example.com")
+	if ids := index.recordIDs(scope, "example.com", endpoint.RecordTypeMX, "10 mail.example.com"); ids != nil {
+		t.Errorf("expected forgotten rr_ids to be gone, got %v", ids)
+	}
+	if ids := index.recordIDs(scope, "example.com", endpoint.RecordTypeMX, "20 mx.example.com"); !reflect.DeepEqual(ids, []int32{5}) {
+		t.Errorf("expected other targets to be kept, got %v", ids)
+	}
+	if _, found := index.zone(scope, "example.com", endpoint.RecordTypeMX); !found {
+		t.Error("expected the zone to be kept after forgetting a target")
+	}
+}
+
+func TestRecordIndexTXTTargetsAreCaseSensitive(t *testing.T) {
+	index := newRecordIndex()
+	scope := recordScope{smart: "smart"}
+
+	index.setRecord(scope, "example.com", "example.com", endpoint.RecordTypeTXT, "Owner=A", 6)
+	if ids := index.recordIDs(scope, "example.com", endpoint.RecordTypeTXT, "owner=a"); ids != nil {
+		t.Errorf("expected TXT targets to differ by case, got %v", ids)
+	}
+}
+
+func TestRecordIndexResetZone(t *testing.T) {
+	index := newRecordIndex()
+	internal := recordScope{smart: "smart", view: "internal"}
+	external := recordScope{smart: "smart", view: "external"}
+
+	index.setRecord(internal, "example.com", "www.example.com", endpoint.RecordTypeA, "10.0.0.1", 1)
+	index.setRecord(internal, "sub.example.com", "api.sub.example.com", endpoint.RecordTypeA, "10.0.0.2", 2)
+	index.setRecord(external, "example.com", "www.example.com", endpoint.RecordTypeA, "192.0.2.1", 3)
+
+	index.resetZone(internal, "example.com")
+
+	if _, found := index.zone(internal, "www.example.com", endpoint.RecordTypeA); found {
+		t.Error("expected the records of the reset zone to be forgotten")
+	}
+	if ids := index.recordIDs(internal, "api.sub.example.com", endpoint.RecordTypeA, "10.0.0.2"); !reflect.DeepEqual(ids, []int32{2}) {
+		t.Errorf("expected records of other zones to be kept, got %v", ids)
+	}
+	if ids := index.recordIDs(external, "www.example.com", endpoint.RecordTypeA, "192.0.2.1"); !reflect.DeepEqual(ids, []int32{3}) {
+		t.Errorf("expected the same zone of other views to be kept, got %v", ids)
+	}
+}
+
+func TestParseRecordID(t *testing.T) {
+	testCases := []struct {
+		id       string
+		expected int32
+		hasError bool
+	}{
+		{id: "42", expected: 42},
+		{id: "2147483647", expected: 2147483647},
+		{id: "2147483648", hasError: true},
+		{id: "0", hasError: true},
+		{id: "-1", hasError: true},
+		{id: "abc", hasError: true},
+		{id: "", hasError: true},
+	}
+
+	for _, tc := range testCases {
+		actual, err := parseRecordID(tc.id)
+		if tc.hasError {
+			if err == nil {
+				t.Errorf("parseRecordID(%q): expected error, got %d", tc.id, actual)
+			}
+			continue
+		}
+		if err != nil || actual != tc.expected {
+			t.Errorf("parseRecordID(%q): expected %d, got %d (%v)", tc.id, tc.expected, actual, err)
+		}
+	}
+}
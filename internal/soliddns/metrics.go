@@ -0,0 +1,34 @@
+package soliddns
+
+import (
+	"expvar"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Metrics are published through expvar and served on the health port under /debug/vars.
+var (
+	// rateLimitDelayedCalls counts calls delayed by the client-side rate limiter, by read/write class
+	rateLimitDelayedCalls = expvar.NewMap("soliddns_rate_limit_delayed_calls_total")
+	// rateLimitWaitSeconds sums the time calls spent waiting for the rate limiter, by read/write class
+	rateLimitWaitSeconds = expvar.NewMap("soliddns_rate_limit_wait_seconds_total")
+	// credentialReloads counts reloads of credential files, by result
+	credentialReloads = expvar.NewMap("soliddns_credential_reloads_total")
+)
+
+// recordRateLimitDelay accounts for a call delayed by the rate limiter
+func recordRateLimitDelay(class string, delay time.Duration) {
+	rateLimitDelayedCalls.Add(class, 1)
+	rateLimitWaitSeconds.AddFloat(class, delay.Seconds())
+	log.Debugf("SOLIDserver %s call delayed %s by rate limit", class, delay)
+}
+
+// recordCredentialReload accounts for a changed or failed reload of the credential files
+func recordCredentialReload(err error) {
+	if err != nil {
+		credentialReloads.Add("error", 1)
+		return
+	}
+	credentialReloads.Add("success", 1)
+}
@@ -0,0 +1,80 @@
+package soliddns
+
+import (
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Class parameters holding the external-dns labels of a record
+const (
+	classParameterOwner    = "external_dns_owner"
+	classParameterResource = "external_dns_resource"
+)
+
+// ownershipLabels maps the labels kept on records to their class parameters
+var ownershipLabels = []struct {
+	label     string
+	parameter string
+}{
+	{label: endpoint.OwnerLabelKey, parameter: classParameterOwner},
+	{label: endpoint.ResourceLabelKey, parameter: classParameterResource},
+}
+
+// ownershipClassParameters builds the rr_class_parameters holding the owner and resource labels of an endpoint.
+// Parameters:
+//   - labels: Labels of the endpoint
+//
+// Returns:
+//   - Class parameter entries, nil if the endpoint has neither label
+func ownershipClassParameters(labels endpoint.Labels) []eip.ApiClassParameterInputEntry {
+	var params []eip.ApiClassParameterInputEntry
+	for _, l := range ownershipLabels {
+		if value := labels[l.label]; value != "" {
+			params = append(params, eip.ApiClassParameterInputEntry{Name: eip.PtrString(l.parameter), Value: eip.PtrString(value)})
+		}
+	}
+	return params
+}
+
+// staleOwnershipClassParameters returns the ownership class parameters a record loses when
+// it is updated from the current to the desired labels.
+// Parameters:
+//   - current: Labels of the record as currently present in SOLIDserver
+//   - desired: Desired labels of the record
+//
+// Returns:
+//   - Names of the class parameters to delete from the record
+func staleOwnershipClassParameters(current, desired endpoint.Labels) []string {
+	var names []string
+	for _, l := range ownershipLabels {
+		if current[l.label] != "" && desired[l.label] == "" {
+			names = append(names, l.parameter)
+		}
+	}
+	return names
+}
+
+// ownershipChanged reports whether the owner or resource label differs between two label sets
+func ownershipChanged(current, desired endpoint.Labels) bool {
+	for _, l := range ownershipLabels {
+		if current[l.label] != desired[l.label] {
+			return true
+		}
+	}
+	return false
+}
+
+// labelsFromClassParameters restores the owner and resource labels from the rr_class_parameters of a record.
+// Other class parameters are ignored.
+// Parameters:
+//   - labels: Labels to fill in, labels already set are kept
+//   - classParameters: Class parameter entries of the record
+func labelsFromClassParameters(labels endpoint.Labels, classParameters []eip.ApiClassParameterOutputEntry) {
+	for _, param := range classParameters {
+		for _, l := range ownershipLabels {
+			if param.GetName() == l.parameter && param.GetValue() != "" && labels[l.label] == "" {
+				labels[l.label] = param.GetValue()
+			}
+		}
+	}
+}
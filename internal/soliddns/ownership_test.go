@@ -0,0 +1,132 @@
+package soliddns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// classParameterOutput builds the class parameter entries of a listed record from name and value pairs
+func classParameterOutput(pairs ...string) []eip.ApiClassParameterOutputEntry {
+	var params []eip.ApiClassParameterOutputEntry
+	for i := 0; i+1 < len(pairs); i += 2 {
+		params = append(params, eip.ApiClassParameterOutputEntry{Name: eip.PtrString(pairs[i]), Value: eip.PtrString(pairs[i+1])})
+	}
+	return params
+}
+
+func TestOwnershipClassParameters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   endpoint.Labels
+		expected []string
+	}{
+		{name: "no labels"},
+		{name: "unrelated labels", labels: endpoint.Labels{"other": "value"}},
+		{name: "owner only", labels: endpoint.Labels{endpoint.OwnerLabelKey: "cluster-a"}, expected: []string{"external_dns_owner=cluster-a"}},
+		{
+			name:     "owner and resource",
+			labels:   endpoint.Labels{endpoint.OwnerLabelKey: "cluster-a", endpoint.ResourceLabelKey: "ingress/default/web app"},
+			expected: []string{"external_dns_owner=cluster-a", "external_dns_resource=ingress/default/web app"},
+		},
+	}
+
+	for _, tc := range testCases {
+		var actual []string
+		for _, param := range ownershipClassParameters(tc.labels) {
+			actual = append(actual, param.GetName()+"="+param.GetValue())
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestStaleOwnershipClassParameters(t *testing.T) {
+	current := endpoint.Labels{endpoint.OwnerLabelKey: "cluster-a", endpoint.ResourceLabelKey: "ingress/default/web"}
+
+	if actual := staleOwnershipClassParameters(current, endpoint.Labels{endpoint.OwnerLabelKey: "cluster-b"}); !reflect.DeepEqual(actual, []string{classParameterResource}) {
+		t.Errorf("expected the resource parameter to be deleted, got %v", actual)
+	}
+	if actual := staleOwnershipClassParameters(current, current); actual != nil {
+		t.Errorf("expected nothing to delete for unchanged labels, got %v", actual)
+	}
+}
+
+func TestLabelsFromClassParameters(t *testing.T) {
+	testCases := []struct {
+		name            string
+		classParameters []eip.ApiClassParameterOutputEntry
+		owner           string
+		resource        string
+	}{
+		{name: "none"},
+		{name: "empty value", classParameters: classParameterOutput("external_dns_owner", "")},
+		{name: "other parameters", classParameters: classParameterOutput("site", "paris", "comment", "managed")},
+		{
+			name:            "owner and resource",
+			classParameters: classParameterOutput("site", "paris", "external_dns_owner", "cluster-a", "external_dns_resource", "ingress/default/web"),
+			owner:           "cluster-a",
+			resource:        "ingress/default/web",
+		},
+	}
+
+	for _, tc := range testCases {
+		labels := endpoint.NewLabels()
+		labelsFromClassParameters(labels, tc.classParameters)
+		if labels[endpoint.OwnerLabelKey] != tc.owner || labels[endpoint.ResourceLabelKey] != tc.resource {
+			t.Errorf("%s: expected owner %q and resource %q, got %v", tc.name, tc.owner, tc.resource, labels)
+		}
+		if len(labels) > 2 {
+			t.Errorf("%s: expected only ownership labels, got %v", tc.name, labels)
+		}
+	}
+}
+
+func TestRecordConverterOwnershipLabels(t *testing.T) {
+	unowned := newRecordData("www.example.com", "A", "300", "192.0.2.1")
+	owned := newRecordData("www.example.com", "A", "300", "192.0.2.2")
+	owned.SetRrClassParameters(classParameterOutput("external_dns_owner", "cluster-a", "external_dns_resource", "service/default/web"))
+	txt := newRecordData("txt.example.com", "TXT", "300", "hello")
+	txt.SetRrClassParameters(classParameterOutput("external_dns_owner", "cluster-b"))
+
+	converter := newRecordConverter()
+	converter.add([]eip.DataInnerDnsRrData{unowned, owned, txt})
+	endpoints := converter.result()
+	if len(endpoints) != 2 {
+		t.Fatalf("expected 2 endpoints, got %v", endpoints)
+	}
+	if labels := endpoints[0].Labels; labels[endpoint.OwnerLabelKey] != "cluster-a" || labels[endpoint.ResourceLabelKey] != "service/default/web" {
+		t.Errorf("expected the grouped record to carry the ownership labels, got %v", labels)
+	}
+	if labels := endpoints[1].Labels; labels[endpoint.OwnerLabelKey] != "cluster-b" {
+		t.Errorf("expected the TXT record to be owned by cluster-b, got %v", labels)
+	}
+}
+
+func TestRecordWritesCarryOwnership(t *testing.T) {
+	api, server := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, nil)
+	api.index.setRecord(recordScope{smart: "smart"}, "example.com", "www.example.com", endpoint.RecordTypeA, "192.0.2.1", 10)
+
+	current := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+	current.Labels[endpoint.OwnerLabelKey] = "cluster-a"
+	current.Labels[endpoint.ResourceLabelKey] = "service/default/web"
+	desired := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2")
+	desired.Labels[endpoint.OwnerLabelKey] = "cluster-b"
+
+	if err := api.RecordUpdate(context.Background(), current, desired); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The unchanged target is rewritten for the new owner, the added one is created with it
+	expected := []string{
+		`PUT /dns/rr/edit class_parameters_to_delete=["external_dns_resource"] rr_class_parameters=[{"name":"external_dns_owner","value":"cluster-b"}] rr_id=10 rr_ttl=300 rr_value1=192.0.2.1`,
+		`POST /dns/rr/add rr_class_parameters=[{"name":"external_dns_owner","value":"cluster-b"}] rr_name=www.example.com rr_ttl=300 rr_type=A rr_value1=192.0.2.2 server_name=smart view_name=`,
+	}
+	if actual := server.recorded(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected calls %q, got %q", expected, actual)
+	}
+}
@@ -3,6 +3,9 @@ package soliddns
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	"sigs.k8s.io/external-dns/endpoint"
@@ -15,34 +18,78 @@ type Provider struct {
 	provider.BaseProvider
 	client       EfficientIPClient
 	domainFilter endpoint.DomainFilter
-	context      context.Context
 	config       *EfficientIPConfig
+	nameFilter   *regexp.Regexp
+	cache        *recordCache
+	router       *viewRouter // Views of A and AAAA targets by address range (nil if not configured)
+
+	reverseMu    sync.Mutex
+	reverseZones *reverseZoneResolver
+
+	driftMu sync.Mutex
+	drifted map[string]*viewCopies // Copies of the records reported as drifted by the last listing, by update key
 }
 
 // Records fetches all DNS records from configured zones
 func (p *Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	log.Debugf("Fetching DNS records from EfficientIP SolidDNS")
 
-	zones, err := p.Zones()
+	zones, err := p.Zones(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch zones: %w", err)
 	}
 
-	var endpoints []*endpoint.Endpoint
-	for _, zone := range zones {
-		log.Debugf("Fetching DNS records from Zone %s", zone.Name)
+	zoneRecords, err := p.listZoneRecords(ctx, zones)
+	if err != nil {
+		return nil, err
+	}
 
-		records, err := p.client.RecordList(*zone)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get records for zone %s: %w", zone.Name, err)
+	// Records are written to every view, drifted ones are kept so the next apply can repair them
+	merged, drifted := mergeViews(p.config, p.router, zones, zoneRecords)
+	p.driftMu.Lock()
+	p.drifted = drifted
+	p.driftMu.Unlock()
+	endpoints := p.filterByName(merged)
+
+	// Report PTR tracking on listed records so plans match adjusted endpoints
+	for _, ep := range endpoints {
+		if p.ptrRecordsEnabled(ep) {
+			p.addPTRRecordTracking(ep)
 		}
-		endpoints = append(endpoints, records...)
 	}
 
 	log.Debugf("Fetched %d records from EfficientIP SolidDNS", len(endpoints))
 	return endpoints, nil
 }
 
+// listZoneRecords lists the records of all zones with a bounded pool of workers.
+// Results are returned in zone order; the first failing zone cancels the remaining listings.
+func (p *Provider) listZoneRecords(ctx context.Context, zones []*ZoneAuth) ([][]*endpoint.Endpoint, error) {
+	results := make([][]*endpoint.Endpoint, len(zones))
+	err := runWorkers(ctx, len(zones), p.config.ListConcurrency, true, func(ctx context.Context, i int) error {
+		zone := zones[i]
+		records, generation, found := p.cache.getRecords(zone.scope().key(), zone.Name)
+		if found {
+			log.Debugf("Using %d cached records from Zone %s of %s", len(records), zone.Name, zone.scope())
+			results[i] = records
+			return nil
+		}
+
+		log.Debugf("Fetching DNS records from Zone %s of %s", zone.Name, zone.scope())
+		records, err := p.client.RecordList(ctx, *zone)
+		if err != nil {
+			return fmt.Errorf("failed to get records for zone %s of %s: %w", zone.Name, zone.scope(), err)
+		}
+		p.cache.setRecords(zone.scope().key(), zone.Name, records, generation)
+		results[i] = records
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	log.Info("Applying DNS changes to EfficientIP SolidDNS")
 
@@ -51,47 +98,104 @@ func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) erro
 		return nil
 	}
 
-	// Process deletion first
-	if err := p.processDeletions(changes.Delete); err != nil {
-		return err
-	}
-	// Process updateOld (deletions for updates)
-	if err := p.processDeletions(changes.UpdateOld); err != nil {
-		return err
+	// Bound the whole apply so a slow appliance can't hold the sync loop forever
+	if p.config.ApplyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.ApplyTimeout)
+		defer cancel()
 	}
-	// Process creates (including updateNew)
-	if err := p.processCreations(changes.Create); err != nil {
-		return err
+
+	// Reload reverse zones so PTR records land in zones created since the last run
+	if p.config.CreatePTR {
+		if err := p.refreshReverseZones(ctx); err != nil {
+			log.Warnf("Failed to refresh reverse zones, PTR records will be skipped: %v", err)
+		}
 	}
 
-	if err := p.processCreations(changes.UpdateNew); err != nil {
+	// Drop cached records of the touched zones, even if only part of the changes were applied
+	defer p.cache.invalidateNames(changedNames(changes))
+
+	// Pair updates by record identity, unmatched ones become delete and create
+	updates, staleOld, staleNew := pairUpdates(changes.UpdateOld, changes.UpdateNew)
+
+	// Changes of different names are applied concurrently, changes of one name in order
+	groups := groupChanges(changes, updates, staleOld, staleNew)
+	err := runWorkers(ctx, len(groups), p.config.ApplyConcurrency, false, func(ctx context.Context, i int) error {
+		return p.applyChangeGroup(ctx, groups[i])
+	})
+	if err != nil {
 		return err
 	}
+
 	log.Info("Successfully applied all DNS changes to EfficientIP SolidDNS")
 	return nil
 }
 
+// applyChangeGroup applies the changes of a single record name.
+// Deletions run first so replaced records never collide with their successors.
+func (p *Provider) applyChangeGroup(ctx context.Context, group *changeGroup) error {
+	// Process deletion first (including updateOld without a matching updateNew)
+	if err := p.processDeletions(ctx, group.deletes); err != nil {
+		return err
+	}
+	// Process in-place updates
+	if err := p.processUpdates(ctx, group.updates); err != nil {
+		return err
+	}
+	// Process creates (including updateNew without a matching updateOld)
+	return p.processCreations(ctx, group.creates)
+}
+
 // processDeletions handles deletion of endpoints
-func (p *Provider) processDeletions(endpoints []*endpoint.Endpoint) error {
+func (p *Provider) processDeletions(ctx context.Context, endpoints []*endpoint.Endpoint) error {
 	for _, ep := range endpoints {
-		if err := p.DeleteChanges(p.context, ep); err != nil {
+		if err := p.DeleteChanges(ctx, ep); err != nil {
 			return fmt.Errorf("failed to delete endpoint %s: %w", ep.DNSName, err)
 		}
 	}
 	return nil
 }
 
+// processUpdates handles in-place updates of endpoints
+func (p *Provider) processUpdates(ctx context.Context, updates []endpointUpdate) error {
+	for _, update := range updates {
+		if err := p.UpdateChanges(ctx, update.current, update.desired); err != nil {
+			return fmt.Errorf("failed to update endpoint %s: %w", update.desired.DNSName, err)
+		}
+	}
+	return nil
+}
+
 // processCreations handles creation of endpoints
-func (p *Provider) processCreations(endpoints []*endpoint.Endpoint) error {
+func (p *Provider) processCreations(ctx context.Context, endpoints []*endpoint.Endpoint) error {
 	for _, ep := range endpoints {
-		if err := p.CreateChanges(p.context, ep); err != nil {
+		if err := p.CreateChanges(ctx, ep); err != nil {
 			return fmt.Errorf("failed to create endpoint %s: %w", ep.DNSName, err)
 		}
 	}
 	return nil
 }
 
-// AdjustEndpoints modifies endpoint before they are processed
+// filterByName keeps only endpoints matching the configured name regex
+func (p *Provider) filterByName(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	if p.nameFilter == nil {
+		return endpoints
+	}
+
+	filtered := endpoints[:0]
+	for _, ep := range endpoints {
+		if !p.nameFilter.MatchString(ep.DNSName) {
+			log.Debugf("Ignoring record '%s' (doesn't match name filter)", ep.DNSName)
+			continue
+		}
+		filtered = append(filtered, ep)
+	}
+	return filtered
+}
+
+// AdjustEndpoints modifies endpoint before they are processed.
+// Endpoints selecting a view or smart they may not write to fail the whole adjustment: dropping
+// them would make external-dns delete their existing records.
 func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
 	if len(endpoints) == 0 {
 		return endpoints, nil
@@ -100,20 +204,27 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 	adjusted := make([]*endpoint.Endpoint, 0, len(endpoints))
 
 	for _, ep := range endpoints {
+		if err := p.adjustScope(ep); err != nil {
+			return nil, fmt.Errorf("invalid endpoint %s %s: %w", ep.DNSName, ep.RecordType, err)
+		}
+
+		// Only listed records may be marked as drifted
+		ep.DeleteProviderSpecificProperty(providerSpecificEfficientipDrift)
+
 		// Set default ttl if not configured
 		if !ep.RecordTTL.IsConfigured() {
 			ep.RecordTTL = endpoint.TTL(p.config.DefaultTTL)
 		}
 
-		adjusted = append(adjusted, ep)
-
-		// skip PTR handling if disabled
-		if !p.config.CreatePTR {
-			continue
+		// Normalize IPv6 targets so plans compare against listed records
+		for i, target := range ep.Targets {
+			ep.Targets[i] = normalizeTarget(ep.RecordType, target)
 		}
 
-		// Add PTR tracking for A records
-		if ep.RecordType == endpoint.RecordTypeA {
+		adjusted = append(adjusted, ep)
+
+		// Add PTR tracking for records with managed reverse records
+		if p.ptrRecordsEnabled(ep) {
 			p.addPTRRecordTracking(ep)
 		}
 	}
@@ -122,23 +233,40 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 
 // addPTRRecordTracking adds provider-specific metadata for PTR record tracking
 func (p *Provider) addPTRRecordTracking(ep *endpoint.Endpoint) {
-	found := false
+	setProviderSpecific(ep, providerSpecificEfficientipPtrRecord, "true")
+}
+
+// setProviderSpecific sets a provider-specific property, replacing an existing value
+func setProviderSpecific(ep *endpoint.Endpoint, name, value string) {
 	for i := range ep.ProviderSpecific {
-		if ep.ProviderSpecific[i].Name == providerSpecificEfficientipPtrRecord {
-			ep.ProviderSpecific[i].Value = "true"
-			found = true
-			break
+		if ep.ProviderSpecific[i].Name == name {
+			ep.ProviderSpecific[i].Value = value
+			return
 		}
 	}
+	ep.WithProviderSpecific(name, value)
+}
 
-	if !found {
-		ep.WithProviderSpecific(providerSpecificEfficientipPtrRecord, "true")
-	}
+// Health reports the state of the connection to SOLIDserver
+func (p *Provider) Health() HealthStatus {
+	return p.client.Health()
+}
+
+// RefreshCache drops all cached zones and records so the next sync lists them again
+func (p *Provider) RefreshCache() {
+	log.Info("Dropping cached zones and records")
+	p.cache.invalidate()
 }
 
 // Zones returns all DNS zones matching the domain filter
-func (p *Provider) Zones() ([]*ZoneAuth, error) {
-	zones, err := p.client.ZonesList(p.config)
+func (p *Provider) Zones(ctx context.Context) ([]*ZoneAuth, error) {
+	zones, generation, found := p.cache.getZones()
+	if found {
+		log.Debugf("Using %d cached zones", len(zones))
+		return zones, nil
+	}
+
+	zones, err := p.client.ZonesList(ctx, p.config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list zones: %w", err)
 	}
@@ -152,11 +280,32 @@ func (p *Provider) Zones() ([]*ZoneAuth, error) {
 		filtered = append(filtered, zone)
 	}
 	log.Debugf("Found %d matching zones", len(filtered))
+	p.cache.setZones(filtered, generation)
 	return filtered, nil
 }
 
-// DeleteChanges handles deletion of DNS records
-func (p *Provider) DeleteChanges(_ context.Context, ep *endpoint.Endpoint) error {
+// DeleteChanges handles deletion of DNS records from the views their targets are routed to.
+// Drifted records are deleted from every view holding a copy.
+func (p *Provider) DeleteChanges(ctx context.Context, ep *endpoint.Endpoint) error {
+	if copies := p.driftedCopies(ep); copies != nil {
+		for _, scope := range copies.scopes {
+			if err := p.deleteEndpoint(ctx, copies.inScope(scope)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, part := range p.routeEndpoint(ep) {
+		if err := p.deleteEndpoint(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteEndpoint handles deletion of the DNS records of an endpoint
+func (p *Provider) deleteEndpoint(ctx context.Context, ep *endpoint.Endpoint) error {
 	if p.config.DryRun {
 		for _, target := range ep.Targets {
 			log.Infof("[DryRun] Would delete %s record '%s' -> '%s'",
@@ -165,13 +314,20 @@ func (p *Provider) DeleteChanges(_ context.Context, ep *endpoint.Endpoint) error
 				target,
 			)
 		}
+		if p.ptrRecordsEnabled(ep) {
+			p.deletePTRRecords(ctx, ep)
+		}
 		return nil
 	}
 
-	if err := p.client.RecordDelete(ep); err != nil {
+	if err := p.client.RecordDelete(ctx, ep); err != nil {
 		return fmt.Errorf("failed to delete record: %w", err)
 	}
 
+	if p.ptrRecordsEnabled(ep) {
+		p.deletePTRRecords(ctx, ep)
+	}
+
 	for _, target := range ep.Targets {
 		log.Infof("Deleted %s record '%s' -> '%s'",
 			ep.RecordType,
@@ -183,8 +339,99 @@ func (p *Provider) DeleteChanges(_ context.Context, ep *endpoint.Endpoint) error
 	return nil
 }
 
-// CreateChanges handles creation of DNS records
-func (p *Provider) CreateChanges(_ context.Context, ep *endpoint.Endpoint) error {
+// UpdateChanges handles in-place updates of DNS records keeping the same name and type.
+// Endpoints routed to several views are updated view by view; views gaining or losing
+// all targets get their records created or deleted. Drifted records are repaired view by view.
+func (p *Provider) UpdateChanges(ctx context.Context, current, desired *endpoint.Endpoint) error {
+	if copies := p.driftedCopies(current); copies != nil {
+		return p.repairDrift(ctx, copies, desired)
+	}
+
+	currentParts, desiredParts := p.routeEndpoint(current), p.routeEndpoint(desired)
+	if len(currentParts) == 1 && len(desiredParts) == 1 {
+		return p.updateEndpoint(ctx, currentParts[0], desiredParts[0])
+	}
+
+	currentByView := make(map[string]*endpoint.Endpoint, len(currentParts))
+	for _, part := range currentParts {
+		currentByView[viewOf(part)] = part
+	}
+	desiredViews := make(map[string]bool, len(desiredParts))
+	for _, part := range desiredParts {
+		desiredViews[viewOf(part)] = true
+		currentPart, found := currentByView[viewOf(part)]
+		switch {
+		case !found:
+			if err := p.createEndpoint(ctx, part); err != nil {
+				return err
+			}
+		case !sameRecord(currentPart, part):
+			if err := p.updateEndpoint(ctx, currentPart, part); err != nil {
+				return err
+			}
+		}
+	}
+	for _, part := range currentParts {
+		if desiredViews[viewOf(part)] {
+			continue
+		}
+		if err := p.deleteEndpoint(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateEndpoint handles the in-place update of the DNS records of an endpoint
+func (p *Provider) updateEndpoint(ctx context.Context, current, desired *endpoint.Endpoint) error {
+	if p.config.DryRun {
+		log.Infof("[DryRun] Would update %s record '%s' -> '%s' (TTL: %d) to '%s' (TTL: %d)",
+			desired.RecordType,
+			desired.DNSName,
+			current.Targets,
+			current.RecordTTL,
+			desired.Targets,
+			desired.RecordTTL,
+		)
+	} else {
+		if err := p.client.RecordUpdate(ctx, current, desired); err != nil {
+			return fmt.Errorf("failed to update record: %w", err)
+		}
+
+		log.Infof("Updated %s record '%s' -> '%s' (TTL: %d)",
+			desired.RecordType,
+			desired.DNSName,
+			desired.Targets,
+			desired.RecordTTL,
+		)
+	}
+
+	// Move PTR records along with changed targets
+	if p.ptrRecordsEnabled(desired) {
+		_, removed, added := diffTargets(desired.RecordType, current.Targets, desired.Targets)
+		if len(removed) > 0 {
+			p.deletePTRRecords(ctx, withTargets(current, removed))
+		}
+		if len(added) > 0 {
+			p.createPTRRecords(ctx, withTargets(desired, added))
+		}
+	}
+
+	return nil
+}
+
+// CreateChanges handles creation of DNS records in the views their targets are routed to
+func (p *Provider) CreateChanges(ctx context.Context, ep *endpoint.Endpoint) error {
+	for _, part := range p.routeEndpoint(ep) {
+		if err := p.createEndpoint(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createEndpoint handles creation of the DNS records of an endpoint
+func (p *Provider) createEndpoint(ctx context.Context, ep *endpoint.Endpoint) error {
 	if p.config.DryRun {
 		for _, target := range ep.Targets {
 			log.Infof("[DryRun] Would create %s record '%s' -> '%s' (TTL: %d)",
@@ -194,13 +441,20 @@ func (p *Provider) CreateChanges(_ context.Context, ep *endpoint.Endpoint) error
 				ep.RecordTTL,
 			)
 		}
+		if p.ptrRecordsEnabled(ep) {
+			p.createPTRRecords(ctx, ep)
+		}
 		return nil
 	}
 
-	if err := p.client.RecordAdd(ep); err != nil {
+	if err := p.client.RecordAdd(ctx, ep); err != nil {
 		return fmt.Errorf("failed to create record: %w", err)
 	}
 
+	if p.ptrRecordsEnabled(ep) {
+		p.createPTRRecords(ctx, ep)
+	}
+
 	for _, target := range ep.Targets {
 		log.Infof("Created %s record '%s' -> '%s' (TTL: %d)",
 			ep.RecordType,
@@ -212,3 +466,105 @@ func (p *Provider) CreateChanges(_ context.Context, ep *endpoint.Endpoint) error
 
 	return nil
 }
+
+// endpointUpdate is a pair of current and desired endpoints sharing the same record identity
+type endpointUpdate struct {
+	current *endpoint.Endpoint
+	desired *endpoint.Endpoint
+}
+
+// pairUpdates matches UpdateOld and UpdateNew endpoints by name, type and set identifier.
+// Endpoints without a counterpart changed identity and must be deleted or created instead.
+func pairUpdates(updateOld, updateNew []*endpoint.Endpoint) (updates []endpointUpdate, staleOld, staleNew []*endpoint.Endpoint) {
+	current := make(map[string]*endpoint.Endpoint, len(updateOld))
+	for _, ep := range updateOld {
+		current[updateKey(ep)] = ep
+	}
+
+	for _, ep := range updateNew {
+		key := updateKey(ep)
+		if old, found := current[key]; found {
+			updates = append(updates, endpointUpdate{current: old, desired: ep})
+			delete(current, key)
+			continue
+		}
+		staleNew = append(staleNew, ep)
+	}
+
+	for _, ep := range updateOld {
+		if _, found := current[updateKey(ep)]; found {
+			staleOld = append(staleOld, ep)
+		}
+	}
+	return updates, staleOld, staleNew
+}
+
+// changeGroup holds the changes of one record name
+type changeGroup struct {
+	name    string
+	deletes []*endpoint.Endpoint
+	updates []endpointUpdate
+	creates []*endpoint.Endpoint
+}
+
+// groupChanges splits the changes of an apply by lowercased record name.
+// Groups keep the order in which names first appear in the changes.
+func groupChanges(changes *plan.Changes, updates []endpointUpdate, staleOld, staleNew []*endpoint.Endpoint) []*changeGroup {
+	var groups []*changeGroup
+	byName := make(map[string]*changeGroup)
+	group := func(name string) *changeGroup {
+		key := strings.ToLower(name)
+		g, found := byName[key]
+		if !found {
+			g = &changeGroup{name: key}
+			byName[key] = g
+			groups = append(groups, g)
+		}
+		return g
+	}
+
+	for _, ep := range changes.Delete {
+		g := group(ep.DNSName)
+		g.deletes = append(g.deletes, ep)
+	}
+	for _, ep := range staleOld {
+		g := group(ep.DNSName)
+		g.deletes = append(g.deletes, ep)
+	}
+	for _, update := range updates {
+		g := group(update.desired.DNSName)
+		g.updates = append(g.updates, update)
+	}
+	for _, ep := range changes.Create {
+		g := group(ep.DNSName)
+		g.creates = append(g.creates, ep)
+	}
+	for _, ep := range staleNew {
+		g := group(ep.DNSName)
+		g.creates = append(g.creates, ep)
+	}
+	return groups
+}
+
+// changedNames returns the record names touched by the changes
+func changedNames(changes *plan.Changes) []string {
+	var names []string
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateOld, changes.UpdateNew, changes.Delete} {
+		for _, ep := range endpoints {
+			names = append(names, ep.DNSName)
+		}
+	}
+	return names
+}
+
+// updateKey returns the record identity used to pair update endpoints
+func updateKey(ep *endpoint.Endpoint) string {
+	return strings.ToLower(ep.DNSName) + ":" + ep.RecordType + ":" + ep.SetIdentifier
+}
+
+// withTargets returns a copy of the endpoint restricted to the given targets
+func withTargets(ep *endpoint.Endpoint, targets []string) *endpoint.Endpoint {
+	cp := *ep
+	cp.Targets = targets
+	return &cp
+}
@@ -0,0 +1,581 @@
+package soliddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// fakeClient is an EfficientIPClient serving records from memory
+type fakeClient struct {
+	zones      []*ZoneAuth
+	recordList func(ctx context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error)
+
+	mu      sync.Mutex
+	ops     []string             // Applied changes as "<op> <name> <type>"
+	applied []*endpoint.Endpoint // Endpoints of the applied changes, in the order of ops
+}
+
+func (f *fakeClient) record(op string, ep *endpoint.Endpoint) {
+	// Give other workers a chance to interleave
+	time.Sleep(time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, op+" "+ep.DNSName+" "+ep.RecordType)
+	f.applied = append(f.applied, ep)
+}
+
+func (f *fakeClient) ZonesList(_ context.Context, _ *EfficientIPConfig) ([]*ZoneAuth, error) {
+	return f.zones, nil
+}
+
+func (f *fakeClient) RecordAdd(_ context.Context, ep *endpoint.Endpoint) error {
+	f.record("create", ep)
+	return nil
+}
+
+func (f *fakeClient) RecordDelete(_ context.Context, ep *endpoint.Endpoint) error {
+	f.record("delete", ep)
+	return nil
+}
+
+func (f *fakeClient) RecordUpdate(_ context.Context, _, desired *endpoint.Endpoint) error {
+	f.record("update", desired)
+	return nil
+}
+
+func (f *fakeClient) RecordList(ctx context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+	return f.recordList(ctx, zone)
+}
+
+func (f *fakeClient) Health() HealthStatus { return HealthStatus{Circuit: CircuitClosed} }
+
+func newFakeZones(count int) []*ZoneAuth {
+	zones := make([]*ZoneAuth, 0, count)
+	for i := 0; i < count; i++ {
+		zones = append(zones, &ZoneAuth{Name: fmt.Sprintf("zone%02d.example.com", i), ID: fmt.Sprint(i)})
+	}
+	return zones
+}
+
+func TestRecordsConcurrentListingOrder(t *testing.T) {
+	var running, peak atomic.Int32
+	client := &fakeClient{
+		zones: newFakeZones(20),
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				seen := peak.Load()
+				if current <= seen || peak.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			// Finish later zones first to shuffle completion order
+			id, _ := strconv.Atoi(zone.ID)
+			time.Sleep(time.Duration(20-id) * time.Millisecond)
+			return []*endpoint.Endpoint{endpoint.NewEndpoint("www."+zone.Name, endpoint.RecordTypeA, "192.0.2.1")}, nil
+		},
+	}
+	p := &Provider{client: client, config: &EfficientIPConfig{ListConcurrency: 4}, cache: newRecordCache(0, 0)}
+
+	endpoints, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(endpoints) != len(client.zones) {
+		t.Fatalf("expected %d endpoints, got %d", len(client.zones), len(endpoints))
+	}
+	for i, ep := range endpoints {
+		if expected := "www." + client.zones[i].Name; ep.DNSName != expected {
+			t.Errorf("endpoint %d: expected %s, got %s", i, expected, ep.DNSName)
+		}
+	}
+	if peak.Load() > 4 {
+		t.Errorf("expected at most 4 concurrent listings, got %d", peak.Load())
+	}
+}
+
+func TestRecordsConcurrentListingError(t *testing.T) {
+	failure := errors.New("zone unavailable")
+	var listed atomic.Int32
+	client := &fakeClient{
+		zones: newFakeZones(50),
+		recordList: func(ctx context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			listed.Add(1)
+			if zone.ID == "1" {
+				return nil, failure
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+				return nil, nil
+			}
+		},
+	}
+	p := &Provider{client: client, config: &EfficientIPConfig{ListConcurrency: 2}, cache: newRecordCache(0, 0)}
+
+	if _, err := p.Records(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("expected zone error, got %v", err)
+	}
+	if listed.Load() == int32(len(client.zones)) {
+		t.Errorf("expected remaining zones to be skipped after the first error")
+	}
+}
+
+func TestFilterByName(t *testing.T) {
+	endpoints := func() []*endpoint.Endpoint {
+		return []*endpoint.Endpoint{
+			endpoint.NewEndpoint("api-1.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+			endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.2"),
+			endpoint.NewEndpoint("api-2.example.com", endpoint.RecordTypeCNAME, "www.example.com"),
+		}
+	}
+	testCases := []struct {
+		name       string
+		nameFilter *regexp.Regexp
+		expected   []string
+	}{
+		{name: "no filter", expected: []string{"api-1.example.com", "www.example.com", "api-2.example.com"}},
+		{name: "matching names", nameFilter: regexp.MustCompile(`^api-[0-9]+\.`), expected: []string{"api-1.example.com", "api-2.example.com"}},
+		{name: "no match", nameFilter: regexp.MustCompile(`^mail\.`)},
+	}
+
+	for _, tc := range testCases {
+		p := &Provider{nameFilter: tc.nameFilter}
+		var names []string
+		for _, ep := range p.filterByName(endpoints()) {
+			names = append(names, ep.DNSName)
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, names)
+		}
+	}
+}
+
+func TestGroupChanges(t *testing.T) {
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeCNAME, "b.example.com"),
+			endpoint.NewEndpoint("c.example.com", endpoint.RecordTypeA, "192.0.2.3"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("A.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.0.2.2"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.0.2.20"),
+		},
+	}
+	updates, staleOld, staleNew := pairUpdates(changes.UpdateOld, changes.UpdateNew)
+	groups := groupChanges(changes, updates, staleOld, staleNew)
+
+	expected := []struct {
+		name    string
+		deletes int
+		updates int
+		creates int
+	}{
+		{name: "a.example.com", deletes: 1, creates: 1},
+		{name: "b.example.com", updates: 1},
+		{name: "c.example.com", creates: 1},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, e := range expected {
+		g := groups[i]
+		if g.name != e.name || len(g.deletes) != e.deletes || len(g.updates) != e.updates || len(g.creates) != e.creates {
+			t.Errorf("group %d: expected %+v, got %s with %d deletes, %d updates, %d creates",
+				i, e, g.name, len(g.deletes), len(g.updates), len(g.creates))
+		}
+	}
+}
+
+func TestApplyChangesPerNameOrdering(t *testing.T) {
+	changes := &plan.Changes{}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("host%d.example.com", i)
+		changes.Create = append(changes.Create, endpoint.NewEndpoint(name, endpoint.RecordTypeCNAME, "target.example.com"))
+		changes.Delete = append(changes.Delete, endpoint.NewEndpoint(name, endpoint.RecordTypeA, "192.0.2.1"))
+	}
+
+	client := &fakeClient{}
+	p := &Provider{client: client, config: &EfficientIPConfig{ApplyConcurrency: 4}, cache: newRecordCache(0, 0)}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(client.ops) != 20 {
+		t.Fatalf("expected 20 changes, got %d", len(client.ops))
+	}
+	deleted := make(map[string]bool)
+	for _, op := range client.ops {
+		var action, name, recordType string
+		fmt.Sscan(op, &action, &name, &recordType)
+		switch action {
+		case "delete":
+			deleted[name] = true
+		case "create":
+			if !deleted[name] {
+				t.Errorf("%s created before its A record was deleted", name)
+			}
+		}
+	}
+}
+
+func TestRecordsAcrossViews(t *testing.T) {
+	records := map[string][]*endpoint.Endpoint{
+		"internal": {
+			endpoint.NewEndpointWithTTL("same.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2"),
+			endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+			endpoint.NewEndpointWithTTL("partial.example.com", endpoint.RecordTypeA, 300, "192.0.2.4"),
+			endpoint.NewEndpointWithTTL("target.example.com", endpoint.RecordTypeCNAME, 300, "a.example.com"),
+		},
+		"external": {
+			endpoint.NewEndpointWithTTL("target.example.com", endpoint.RecordTypeCNAME, 300, "b.example.com"),
+			endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 600, "192.0.2.3"),
+			endpoint.NewEndpointWithTTL("same.example.com", endpoint.RecordTypeA, 300, "192.0.2.2", "192.0.2.1"),
+		},
+	}
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "dns-smart", View: "internal"},
+			{Name: "example.com", ID: "2", Smart: "dns-smart", View: "external"},
+		},
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			return records[zone.View], nil
+		},
+	}
+	config := &EfficientIPConfig{DnsSmart: "dns-smart", DnsViews: []string{"internal", "external"}, ListConcurrency: 2}
+	p := &Provider{client: client, config: config, cache: newRecordCache(0, 0)}
+
+	endpoints, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		name    string
+		view    string
+		drift   bool
+		ttl     endpoint.TTL
+		targets []string
+	}{
+		{name: "same.example.com", ttl: 300, targets: []string{"192.0.2.1", "192.0.2.2"}},
+		{name: "ttl.example.com", drift: true, ttl: 300, targets: []string{"192.0.2.3"}},
+		{name: "partial.example.com", view: "internal", ttl: 300, targets: []string{"192.0.2.4"}},
+		{name: "target.example.com", drift: true, ttl: 300, targets: []string{"a.example.com"}},
+	}
+	if len(endpoints) != len(expected) {
+		t.Fatalf("expected %d endpoints, got %v", len(expected), endpoints)
+	}
+	for i, e := range expected {
+		ep := endpoints[i]
+		view, _ := ep.GetProviderSpecificProperty(providerSpecificEfficientipView)
+		_, drift := ep.GetProviderSpecificProperty(providerSpecificEfficientipDrift)
+		if ep.DNSName != e.name || view != e.view || drift != e.drift || ep.RecordTTL != e.ttl || !reflect.DeepEqual([]string(ep.Targets), e.targets) {
+			t.Errorf("endpoint %d: expected %+v, got %v view=%q drift=%t", i, e, ep, view, drift)
+		}
+	}
+}
+
+func TestApplyChangesRepairsDrift(t *testing.T) {
+	records := map[string][]*endpoint.Endpoint{
+		"internal": {
+			endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+			endpoint.NewEndpointWithTTL("target.example.com", endpoint.RecordTypeCNAME, 300, "a.example.com"),
+			endpoint.NewEndpointWithTTL("orphan.example.com", endpoint.RecordTypeA, 300, "192.0.2.5"),
+		},
+		"external": {
+			endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 600, "192.0.2.3"),
+			endpoint.NewEndpointWithTTL("target.example.com", endpoint.RecordTypeCNAME, 300, "b.example.com"),
+			endpoint.NewEndpointWithTTL("orphan.example.com", endpoint.RecordTypeA, 600, "192.0.2.5"),
+		},
+		"dmz": {
+			endpoint.NewEndpointWithTTL("target.example.com", endpoint.RecordTypeCNAME, 300, "b.example.com"),
+		},
+	}
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "dns-smart", View: "internal"},
+			{Name: "example.com", ID: "2", Smart: "dns-smart", View: "external"},
+			{Name: "example.com", ID: "3", Smart: "dns-smart", View: "dmz"},
+		},
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			return records[zone.View], nil
+		},
+	}
+	config := &EfficientIPConfig{DnsSmart: "dns-smart", DnsViews: []string{"internal", "external"}, AllowedViews: []string{"dmz"}, ListConcurrency: 1, ApplyConcurrency: 1}
+	p := &Provider{client: client, config: config, cache: newRecordCache(0, 0)}
+
+	current, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listed := make(map[string]*endpoint.Endpoint)
+	for _, ep := range current {
+		listed[ep.DNSName] = ep
+	}
+
+	// Desired endpoints never carry the drift property, so the drifted records always differ
+	desired, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+		endpoint.NewEndpointWithTTL("target.example.com", endpoint.RecordTypeCNAME, 300, "b.example.com"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changes := &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{listed["ttl.example.com"], listed["target.example.com"]},
+		UpdateNew: desired,
+		Delete:    []*endpoint.Endpoint{listed["orphan.example.com"]},
+	}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		op   string
+		view string
+	}{
+		// Deleting a drifted record removes every copy
+		{op: "delete orphan.example.com A", view: "internal"},
+		{op: "delete orphan.example.com A", view: "external"},
+		// Only the copies differing from the desired endpoint are written
+		{op: "update ttl.example.com A", view: "external"},
+		// Copies outside the scopes of the desired endpoint are deleted
+		{op: "update target.example.com CNAME", view: "internal"},
+		{op: "delete target.example.com CNAME", view: "dmz"},
+	}
+	if len(client.ops) != len(expected) {
+		t.Fatalf("expected %d operations, got %v", len(expected), client.ops)
+	}
+	for i, e := range expected {
+		if view := viewOf(client.applied[i]); client.ops[i] != e.op || view != e.view {
+			t.Errorf("operation %d: expected %q in view %q, got %q in view %q", i, e.op, e.view, client.ops[i], view)
+		}
+	}
+}
+
+func TestZonesOfSeveralViewsWithoutView(t *testing.T) {
+	records := map[string][]*endpoint.Endpoint{
+		"1": {endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")},
+		"2": {endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.2")},
+	}
+	var listings atomic.Int32
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "dns-smart", View: "internal"},
+			{Name: "example.com", ID: "2", Smart: "dns-smart", View: "external"},
+		},
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			listings.Add(1)
+			return records[zone.ID], nil
+		},
+	}
+	config := &EfficientIPConfig{DnsSmart: "dns-smart", ListConcurrency: 1, ApplyConcurrency: 1}
+	p := &Provider{client: client, config: config, cache: newRecordCache(time.Minute, time.Minute)}
+
+	// Zones of the same name are listed and cached per view, so neither hides the other
+	for i := 0; i < 2; i++ {
+		endpoints, err := p.Records(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, drift := endpoints[0].GetProviderSpecificProperty(providerSpecificEfficientipDrift); len(endpoints) != 1 || !drift {
+			t.Fatalf("listing %d: expected www.example.com to differ between the views, got %v", i, endpoints)
+		}
+	}
+	if count := listings.Load(); count != 2 {
+		t.Errorf("expected each zone to be listed once, got %d listings", count)
+	}
+
+	// The copy of each view is repaired in place
+	current, _ := p.Records(context.Background())
+	desired := endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")
+	if err := p.UpdateChanges(context.Background(), current[0], desired); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.ops) != 1 || client.ops[0] != "update www.example.com A" || viewOf(client.applied[0]) != "external" {
+		t.Errorf("expected only the external copy to be updated, got %v", client.ops)
+	}
+}
+
+func TestApplyChangesPTRRecords(t *testing.T) {
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "smart"},
+			{Name: "2.0.192.in-addr.arpa", ID: "2", Smart: "smart"},
+		},
+	}
+	config := &EfficientIPConfig{DnsSmart: "smart", CreatePTR: true, ApplyConcurrency: 1}
+	p := &Provider{client: client, config: config, cache: newRecordCache(0, 0)}
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			// No managed reverse zone covers 198.51.100.1, only its forward record is written
+			endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.10", "198.51.100.1"),
+			endpoint.NewEndpointWithTTL("alias.example.com", endpoint.RecordTypeCNAME, 300, "www.example.com"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("old.example.com", endpoint.RecordTypeA, 300, "192.0.2.20"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("moved.example.com", endpoint.RecordTypeA, 300, "192.0.2.30", "192.0.2.32"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("moved.example.com", endpoint.RecordTypeA, 300, "192.0.2.31", "192.0.2.32"),
+		},
+	}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"delete old.example.com A",
+		"delete 20.2.0.192.in-addr.arpa PTR",
+		"update moved.example.com A",
+		"delete 30.2.0.192.in-addr.arpa PTR",
+		"create 31.2.0.192.in-addr.arpa PTR",
+		"create www.example.com A",
+		"create 10.2.0.192.in-addr.arpa PTR",
+		"create alias.example.com CNAME",
+	}
+	if !reflect.DeepEqual(client.ops, expected) {
+		t.Fatalf("expected changes %q, got %q", expected, client.ops)
+	}
+	for i, ep := range client.applied {
+		if ep.RecordType != endpoint.RecordTypePTR {
+			continue
+		}
+		// Every PTR points back to the forward record of the preceding A change
+		forward := client.applied[i-1]
+		if forward.RecordType == endpoint.RecordTypePTR {
+			forward = client.applied[i-2]
+		}
+		if len(ep.Targets) != 1 || ep.Targets[0] != forward.DNSName || ep.RecordTTL != 300 {
+			t.Errorf("expected %s to point to %s with TTL 300, got %v (TTL %d)", ep.DNSName, forward.DNSName, ep.Targets, ep.RecordTTL)
+		}
+	}
+}
+
+func TestApplyChangesPTRRecordsPerView(t *testing.T) {
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "2.0.192.in-addr.arpa", ID: "1", Smart: "dns-smart", View: "internal"},
+			{Name: "10.in-addr.arpa", ID: "2", Smart: "dns-smart", View: "internal"},
+			{Name: "10.in-addr.arpa", ID: "3", Smart: "dns-smart", View: "external"},
+			{Name: "2.0.192.in-addr.arpa", ID: "4", Smart: "other-smart", View: "external"},
+		},
+	}
+	p := newRoutedProviderWithPublicView(t, client, "*")
+	p.config.CreatePTR = true
+	p.config.AllowedSmarts = []string{"other-smart"}
+	p.config.ApplyConcurrency = 1
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			// Only the internal view has a reverse zone for 192.0.2.10
+			endpoint.NewEndpointWithTTL("www.example.com", endpoint.RecordTypeA, 300, "192.0.2.10"),
+			// 10.0.0.1 is routed to the internal view, its PTR must not be written to the external one
+			endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.1"),
+			endpoint.NewEndpointWithTTL("other.example.com", endpoint.RecordTypeA, 300, "192.0.2.20").
+				WithProviderSpecific(providerSpecificEfficientipSmart, "other-smart").
+				WithProviderSpecific(providerSpecificEfficientipView, "external"),
+		},
+	}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		op    string
+		smart string
+		view  string
+	}{
+		{op: "create www.example.com A", view: "internal"},
+		{op: "create 10.2.0.192.in-addr.arpa PTR", smart: "dns-smart", view: "internal"},
+		{op: "create www.example.com A", view: "external"},
+		{op: "create app.example.com A", view: "internal"},
+		{op: "create 1.0.0.10.in-addr.arpa PTR", smart: "dns-smart", view: "internal"},
+		{op: "create other.example.com A", smart: "other-smart", view: "external"},
+		{op: "create 20.2.0.192.in-addr.arpa PTR", smart: "other-smart", view: "external"},
+	}
+	if len(client.ops) != len(expected) {
+		t.Fatalf("expected %d changes, got %q", len(expected), client.ops)
+	}
+	for i, e := range expected {
+		ep := client.applied[i]
+		smart, _ := ep.GetProviderSpecificProperty(providerSpecificEfficientipSmart)
+		if view := viewOf(ep); client.ops[i] != e.op || smart != e.smart || view != e.view {
+			t.Errorf("change %d: expected %q in %s/%s, got %q in %s/%s", i, e.op, e.smart, e.view, client.ops[i], smart, view)
+		}
+	}
+}
+
+func TestApplyChangesPTRRecordsDryRun(t *testing.T) {
+	client := &fakeClient{zones: []*ZoneAuth{{Name: "2.0.192.in-addr.arpa", ID: "2", Smart: "smart"}}}
+	config := &EfficientIPConfig{DnsSmart: "smart", CreatePTR: true, DryRun: true, ApplyConcurrency: 1}
+	p := &Provider{client: client, config: config, cache: newRecordCache(0, 0)}
+
+	changes := &plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.10")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("old.example.com", endpoint.RecordTypeA, "192.0.2.20")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("moved.example.com", endpoint.RecordTypeA, "192.0.2.30")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("moved.example.com", endpoint.RecordTypeA, "192.0.2.31")},
+	}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.ops) != 0 {
+		t.Errorf("expected no changes in dry-run mode, got %q", client.ops)
+	}
+}
+
+func TestApplyChangesPTRRecordsDisabled(t *testing.T) {
+	client := &fakeClient{zones: []*ZoneAuth{{Name: "2.0.192.in-addr.arpa", ID: "2", Smart: "smart"}}}
+	p := &Provider{client: client, config: &EfficientIPConfig{DnsSmart: "smart", ApplyConcurrency: 1}, cache: newRecordCache(0, 0)}
+
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.10")}}
+	if err := p.ApplyChanges(context.Background(), changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"create www.example.com A"}; !reflect.DeepEqual(client.ops, expected) {
+		t.Errorf("expected changes %q, got %q", expected, client.ops)
+	}
+}
+
+func TestApplyChangesApplyTimeout(t *testing.T) {
+	// The stubbed appliance never answers before the request context ends
+	api, _ := newStubbedAPI(t, &EfficientIPConfig{DnsSmart: "smart"}, func(call stubCall) (int, string) {
+		<-call.ctx.Done()
+		return http.StatusOK, `{"success":true}`
+	})
+	config := &EfficientIPConfig{DnsSmart: "smart", ApplyTimeout: 20 * time.Millisecond, ApplyConcurrency: 1}
+	p := &Provider{client: api, config: config, cache: newRecordCache(0, 0)}
+
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")}}
+	start := time.Now()
+	if err := p.ApplyChanges(context.Background(), changes); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the apply timeout to abort the changes, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the changes to be aborted after the apply timeout, took %s", elapsed)
+	}
+}
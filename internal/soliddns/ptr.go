@@ -0,0 +1,116 @@
+package soliddns
+
+import (
+	"context"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// ptrRecordsEnabled reports whether reverse records are managed for the endpoint
+func (p *Provider) ptrRecordsEnabled(ep *endpoint.Endpoint) bool {
+	return p.config.CreatePTR && (ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA)
+}
+
+// refreshReverseZones reloads the managed reverse zones used to place PTR records
+func (p *Provider) refreshReverseZones(ctx context.Context) error {
+	// Reverse zones never match the FQDN regex, so list them without it
+	config := *p.config
+	config.FQDNRegEx = ""
+
+	zones, err := p.client.ZonesList(ctx, &config)
+	if err != nil {
+		return err
+	}
+
+	p.reverseMu.Lock()
+	defer p.reverseMu.Unlock()
+	p.reverseZones = newReverseZoneResolver(zones)
+	return nil
+}
+
+// reverseResolver returns the reverse zone resolver, loading the zones on first use
+func (p *Provider) reverseResolver(ctx context.Context) (*reverseZoneResolver, error) {
+	p.reverseMu.Lock()
+	resolver := p.reverseZones
+	p.reverseMu.Unlock()
+	if resolver != nil {
+		return resolver, nil
+	}
+
+	if err := p.refreshReverseZones(ctx); err != nil {
+		return nil, err
+	}
+	p.reverseMu.Lock()
+	defer p.reverseMu.Unlock()
+	return p.reverseZones, nil
+}
+
+// createPTRRecords creates a PTR record for every target of an address endpoint.
+// Failures are logged and do not fail the forward record change.
+func (p *Provider) createPTRRecords(ctx context.Context, ep *endpoint.Endpoint) {
+	for _, ptr := range p.ptrEndpoints(ctx, ep) {
+		if p.config.DryRun {
+			log.Infof("[DryRun] Would create PTR record '%s' -> '%s'", ptr.DNSName, ep.DNSName)
+			continue
+		}
+
+		if err := p.client.RecordAdd(ctx, ptr); err != nil {
+			log.Warnf("Failed to create PTR record %s -> %s: %v", ptr.DNSName, ep.DNSName, err)
+			continue
+		}
+		p.cache.invalidateNames([]string{ptr.DNSName})
+		log.Infof("Created PTR record '%s' -> '%s'", ptr.DNSName, ep.DNSName)
+	}
+}
+
+// deletePTRRecords removes the PTR record of every target of an address endpoint.
+// Failures are logged and do not fail the forward record change.
+func (p *Provider) deletePTRRecords(ctx context.Context, ep *endpoint.Endpoint) {
+	for _, ptr := range p.ptrEndpoints(ctx, ep) {
+		if p.config.DryRun {
+			log.Infof("[DryRun] Would delete PTR record '%s' -> '%s'", ptr.DNSName, ep.DNSName)
+			continue
+		}
+
+		if err := p.client.RecordDelete(ctx, ptr); err != nil {
+			log.Warnf("Failed to delete PTR record %s -> %s: %v", ptr.DNSName, ep.DNSName, err)
+			continue
+		}
+		p.cache.invalidateNames([]string{ptr.DNSName})
+		log.Infof("Deleted PTR record '%s' -> '%s'", ptr.DNSName, ep.DNSName)
+	}
+}
+
+// ptrEndpoints builds the PTR endpoints pointing back to the endpoint name.
+// PTR records live in the same smart and view as their forward records and use the reverse zones
+// of that scope; targets without a managed reverse zone there are skipped with a warning.
+func (p *Provider) ptrEndpoints(ctx context.Context, ep *endpoint.Endpoint) []*endpoint.Endpoint {
+	resolver, err := p.reverseResolver(ctx)
+	if err != nil {
+		log.Warnf("Skipping PTR records for %s: failed to list reverse zones: %v", ep.DNSName, err)
+		return nil
+	}
+
+	ptrs := make([]*endpoint.Endpoint, 0, len(ep.Targets))
+	for _, part := range p.scopeParts(ep) {
+		scope := p.scopeOf(part)
+		for _, target := range part.Targets {
+			zone, name, err := resolver.Resolve(scope, target)
+			if err != nil {
+				if errors.Is(err, ErrNoReverseZone) {
+					log.Warnf("Skipping PTR record for %s -> %s: %v", ep.DNSName, target, err)
+				} else {
+					log.Warnf("Skipping PTR record for %s -> %s: invalid target: %v", ep.DNSName, target, err)
+				}
+				continue
+			}
+
+			log.Debugf("Using reverse zone %s of %s for PTR record %s", zone.Name, scope, name)
+			ptr := endpoint.NewEndpointWithTTL(name, endpoint.RecordTypePTR, ep.RecordTTL, ep.DNSName)
+			ptrs = append(ptrs, withScopeProperties(ptr, zone.Smart, zone.View))
+		}
+	}
+	return ptrs
+}
@@ -0,0 +1,116 @@
+package soliddns
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting the rate of SOLIDserver calls.
+// Tokens refill continuously at rate per second up to burst; every call takes one token
+// and waits for it when the bucket is empty.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second (<= 0 disables the limiter)
+	burst  float64 // Bucket capacity
+	tokens float64 // Available tokens, negative when calls are queued for future tokens
+	last   time.Time
+	now    func() time.Time
+}
+
+// newRateLimiter creates a full token bucket.
+// A rate <= 0 disables limiting, a burst < 1 is raised to 1.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+	l.last = l.now()
+	return l
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it
+func (l *rateLimiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// unreserve returns a token taken by a call that did not happen
+func (l *rateLimiter) unreserve() {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// wait blocks until the caller may issue a call.
+// Returns:
+//   - Time the caller was delayed
+//   - Context error if the caller gave up before a token was available
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	delay := l.reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.unreserve()
+		return 0, ctx.Err()
+	case <-timer.C:
+		return delay, nil
+	}
+}
+
+// throttle waits for the rate limiter matching the kind of call.
+// Parameters:
+//   - ctx: Caller context
+//   - kind: Classification of the call, reads and writes are limited separately
+//
+// Returns:
+//   - Context error if the caller gave up while throttled
+func (e *EfficientIPAPI) throttle(ctx context.Context, kind callKind) error {
+	limiter, class := e.writeLimiter, "write"
+	if kind == callRead {
+		limiter, class = e.readLimiter, "read"
+	}
+
+	delay, err := limiter.wait(ctx)
+	if err != nil {
+		return err
+	}
+	if delay > 0 {
+		recordRateLimitDelay(class, delay)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package soliddns
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+
+	expected := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i, delay := range expected {
+		if actual := limiter.reserve(); actual != delay {
+			t.Errorf("reserve #%d: expected delay %s, got %s", i+1, delay, actual)
+		}
+	}
+
+	// Queued calls consume the refill before new ones are let through
+	now = now.Add(time.Second)
+	if actual := limiter.reserve(); actual != 500*time.Millisecond {
+		t.Errorf("reserve after refill: expected delay 500ms, got %s", actual)
+	}
+
+	// The bucket never holds more than burst tokens
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if actual := limiter.reserve(); actual != 0 {
+			t.Errorf("reserve #%d after idle period: expected no delay, got %s", i+1, actual)
+		}
+	}
+	if actual := limiter.reserve(); actual != 500*time.Millisecond {
+		t.Errorf("reserve beyond burst: expected delay 500ms, got %s", actual)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := newRateLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		if delay, err := limiter.wait(context.Background()); delay != 0 || err != nil {
+			t.Fatalf("disabled limiter: expected no delay, got %s (%v)", delay, err)
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := newRateLimiter(0.001, 1)
+	limiter.reserve()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limiter.wait(ctx); err == nil {
+		t.Errorf("expected context error while throttled")
+	}
+	if limiter.tokens < -0.01 {
+		t.Errorf("expected cancelled wait to give its token back, got %f tokens", limiter.tokens)
+	}
+}
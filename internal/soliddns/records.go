@@ -0,0 +1,148 @@
+package soliddns
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// normalizeTarget returns the canonical form of a record target.
+// IPv6 addresses of AAAA records are compressed (e.g. "0:0::1" becomes "::1") and
+// MX and SRV targets are reduced to single-space separated fields so that targets
+// read from SOLIDserver compare equal to the ones requested by external-dns.
+// Targets that cannot be parsed are returned unchanged.
+// Parameters:
+//   - recordType: DNS record type of the target
+//   - target: Target value to normalize
+//
+// Returns:
+//   - Normalized target value
+func normalizeTarget(recordType, target string) string {
+	switch recordType {
+	case endpoint.RecordTypeAAAA:
+		addr, err := netip.ParseAddr(target)
+		if err != nil || !addr.Is6() {
+			return target
+		}
+		return addr.String()
+	case endpoint.RecordTypeMX, endpoint.RecordTypeSRV:
+		values, err := recordValues(recordType, target)
+		if err != nil {
+			return target
+		}
+		return strings.Join(values, " ")
+	default:
+		return target
+	}
+}
+
+// recordValues splits an external-dns target into SOLIDserver rr_value fields.
+// MX targets ("10 mail.example.com") are split into preference (rr_value1) and
+// exchange (rr_value2), SRV targets ("10 5 443 svc.example.com") into priority,
+// weight, port and target (rr_value1..rr_value4); all other record types use the
+// target as rr_value1.
+// Parameters:
+//   - recordType: DNS record type of the target
+//   - target: Target value in external-dns format
+//
+// Returns:
+//   - Ordered rr_value fields, always containing at least one element
+//   - Error if the target is malformed for the record type
+func recordValues(recordType, target string) ([]string, error) {
+	switch recordType {
+	case endpoint.RecordTypeMX:
+		return splitNumericTarget(recordType, target, "<preference> <exchange>", 1)
+	case endpoint.RecordTypeSRV:
+		return splitNumericTarget(recordType, target, "<priority> <weight> <port> <target>", 3)
+	default:
+		return []string{target}, nil
+	}
+}
+
+// splitNumericTarget splits a target made of numeric fields followed by a host name.
+// Parameters:
+//   - recordType: DNS record type of the target (used in error messages)
+//   - target: Target value in external-dns format
+//   - format: Expected target format (used in error messages)
+//   - numeric: Number of leading 16-bit numeric fields
+//
+// Returns:
+//   - Ordered fields with the trailing dot removed from the host name
+//   - Error if the field count or any numeric field is invalid
+func splitNumericTarget(recordType, target, format string, numeric int) ([]string, error) {
+	fields := strings.Fields(target)
+	if len(fields) != numeric+1 {
+		return nil, fmt.Errorf("%s target '%s' must be in '%s' format", recordType, target, format)
+	}
+	for _, field := range fields[:numeric] {
+		if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid %s field '%s' in target '%s': %w", recordType, field, target, err)
+		}
+	}
+	fields[numeric] = strings.TrimSuffix(fields[numeric], ".")
+	return fields, nil
+}
+
+// recordTarget rebuilds the external-dns target of a SOLIDserver record.
+// MX and SRV records are combined from their individual rr_value fields; other
+// record types use rr_all_value as-is.
+// Parameters:
+//   - rr: API record data object
+//
+// Returns:
+//   - Target value in external-dns format
+func recordTarget(rr eip.DataInnerDnsRrData) string {
+	var values []string
+	switch rr.GetRrType() {
+	case endpoint.RecordTypeMX:
+		values = []string{rr.GetRrValue1(), rr.GetRrValue2()}
+	case endpoint.RecordTypeSRV:
+		values = []string{rr.GetRrValue1(), rr.GetRrValue2(), rr.GetRrValue3(), rr.GetRrValue4()}
+	}
+
+	if len(values) == 0 || slices.Contains(values, "") {
+		return normalizeTarget(rr.GetRrType(), rr.GetRrAllValue())
+	}
+	return normalizeTarget(rr.GetRrType(), strings.Join(values, " "))
+}
+
+// diffTargets compares the targets of two endpoints of the same record type.
+// Parameters:
+//   - recordType: DNS record type of the targets
+//   - current: Current targets
+//   - desired: Desired targets
+//
+// Returns:
+//   - Targets present in both lists
+//   - Targets only present in the current list
+//   - Targets only present in the desired list
+func diffTargets(recordType string, current, desired []string) (common, removed, added []string) {
+	remaining := make(map[string]int, len(current))
+	for _, target := range current {
+		remaining[normalizeTarget(recordType, target)]++
+	}
+
+	for _, target := range desired {
+		target = normalizeTarget(recordType, target)
+		if remaining[target] > 0 {
+			remaining[target]--
+			common = append(common, target)
+		} else {
+			added = append(added, target)
+		}
+	}
+
+	for _, target := range current {
+		target = normalizeTarget(recordType, target)
+		if remaining[target] > 0 {
+			remaining[target]--
+			removed = append(removed, target)
+		}
+	}
+	return common, removed, added
+}
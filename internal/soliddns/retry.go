@@ -0,0 +1,164 @@
+package soliddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// callKind classifies SOLIDserver calls by how safely they can be retried
+type callKind int
+
+const (
+	// callRead is an idempotent list call
+	callRead callKind = iota
+	// callWrite is an idempotent write (update or delete of a given record)
+	callWrite
+	// callCreate is a non-idempotent write, retried only when the request was not processed
+	callCreate
+)
+
+// retryPolicy configures the jittered exponential backoff between attempts of a call
+type retryPolicy struct {
+	maxAttempts int           // Attempts per call including the first one (<= 1 disables retries)
+	baseDelay   time.Duration // Delay before the first retry
+	maxDelay    time.Duration // Upper bound of the delay between attempts
+}
+
+// backoff returns the delay before the given retry (1 for the first retry).
+// The delay doubles with every attempt up to maxDelay and is jittered to [delay/2, delay].
+func (r retryPolicy) backoff(retry int) time.Duration {
+	delay := r.baseDelay
+	for i := 1; i < retry && delay < r.maxDelay; i++ {
+		delay *= 2
+	}
+	if r.maxDelay > 0 && delay > r.maxDelay {
+		delay = r.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// do executes a SOLIDserver call with the rate limiter, circuit breaker, retry policy and per-call deadline.
+// The call receives the request context and returns the HTTP response of the API client;
+// responses with an error status are turned into an APIError.
+// Parameters:
+//   - ctx: Caller context
+//   - kind: Retry classification of the call
+//   - call: Function issuing the API request
+//
+// Returns:
+//   - Error of the last attempt, ErrCircuitOpen if the breaker rejected the call,
+//     or the context error if the caller gave up while throttled or waiting for a retry
+func (e *EfficientIPAPI) do(ctx context.Context, kind callKind, call func(ctx context.Context) (*http.Response, error)) error {
+	for attempt := 1; ; attempt++ {
+		if err := e.throttle(ctx, kind); err != nil {
+			return err
+		}
+		if err := e.breaker.allow(); err != nil {
+			return err
+		}
+
+		resp, node, err := e.attempt(ctx, call)
+		failedOver := false
+		if e.endpoints.shouldFailover(ctx, resp, err) {
+			failedOver = e.endpoints.failover(node, err)
+		} else if !isServerFailure(resp, err) {
+			e.endpoints.succeeded(node)
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			// The caller gave up, this says nothing about the appliance
+			e.breaker.release()
+		case isServerFailure(resp, err):
+			e.breaker.failure(err)
+		default:
+			e.breaker.success()
+		}
+
+		if err == nil {
+			return nil
+		}
+		if attempt >= e.retry.maxAttempts || ctx.Err() != nil || !isRetriable(kind, resp, err) {
+			return err
+		}
+
+		// Another node is available right away, backing off only applies to the same node
+		delay := e.retry.backoff(attempt)
+		if failedOver {
+			delay = 0
+		}
+		log.Warnf("SOLIDserver call failed (attempt %d/%d), retrying in %s: %v", attempt, e.retry.maxAttempts, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (giving up retries: %v)", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt issues a single call to the active node with the per-call context.
+// Parameters:
+//   - ctx: Caller context
+//   - call: Function issuing the API request
+//
+// Returns:
+//   - HTTP response of the call (may be nil on transport errors)
+//   - Index of the node the call was sent to
+//   - Error of the call, an APIError for responses with an error status
+func (e *EfficientIPAPI) attempt(ctx context.Context, call func(ctx context.Context) (*http.Response, error)) (*http.Response, int, error) {
+	node, index := e.endpoints.current()
+	ctx, cancel := e.requestContext(ctx, node)
+	defer cancel()
+
+	resp, err := call(ctx)
+	if err != nil {
+		log.Debugf("SOLIDserver call to %s failed: %v", node, err)
+	}
+	return resp, index, newAPIError(resp, err)
+}
+
+// isServerFailure reports whether a call failed because the appliance is unreachable or unhealthy.
+// Client errors (4xx other than 429) prove the appliance is up and do not count as failures.
+func isServerFailure(resp *http.Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	if resp == nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// isRetriable reports whether a failed call may be attempted again.
+// Reads and idempotent writes are retried on transport errors and server errors.
+// Creations are only retried when SOLIDserver provably did not process the request:
+// the connection could not be established, or the appliance rejected it as busy (ErrServerBusy).
+func isRetriable(kind callKind, resp *http.Response, err error) bool {
+	if resp == nil {
+		if kind != callCreate {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+
+	if errors.Is(err, ErrServerBusy) {
+		return true
+	}
+	return kind != callCreate && resp.StatusCode >= 500
+}
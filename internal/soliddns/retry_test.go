@@ -0,0 +1,112 @@
+package soliddns
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 5, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	testCases := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 100 * time.Millisecond},
+		{retry: 2, max: 200 * time.Millisecond},
+		{retry: 3, max: 400 * time.Millisecond},
+		{retry: 4, max: 800 * time.Millisecond},
+		{retry: 5, max: time.Second},
+		{retry: 20, max: time.Second},
+	}
+
+	for _, tc := range testCases {
+		for i := 0; i < 20; i++ {
+			delay := policy.backoff(tc.retry)
+			if delay < tc.max/2 || delay > tc.max {
+				t.Errorf("backoff(%d): expected delay in [%s, %s], got %s", tc.retry, tc.max/2, tc.max, delay)
+			}
+		}
+	}
+}
+
+func TestIsRetriable(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}
+
+	testCases := []struct {
+		name     string
+		kind     callKind
+		status   int
+		err      error
+		expected bool
+	}{
+		{name: "read transport error", kind: callRead, err: readErr, expected: true},
+		{name: "read server error", kind: callRead, status: http.StatusInternalServerError, expected: true},
+		{name: "read bad request", kind: callRead, status: http.StatusBadRequest, expected: false},
+		{name: "write gateway timeout", kind: callWrite, status: http.StatusGatewayTimeout, expected: true},
+		{name: "write not found", kind: callWrite, status: http.StatusNotFound, expected: false},
+		{name: "create dial error", kind: callCreate, err: dialErr, expected: true},
+		{name: "create transport error", kind: callCreate, err: readErr, expected: false},
+		{name: "create busy", kind: callCreate, status: http.StatusServiceUnavailable, expected: true},
+		{name: "create throttled", kind: callCreate, status: http.StatusTooManyRequests, expected: true},
+		{name: "create server error", kind: callCreate, status: http.StatusInternalServerError, expected: false},
+	}
+
+	for _, tc := range testCases {
+		var resp *http.Response
+		err := tc.err
+		if tc.status != 0 {
+			resp = &http.Response{StatusCode: tc.status}
+			err = newAPIError(resp, nil)
+		}
+		if actual := isRetriable(tc.kind, resp, err); actual != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	breaker.failure(failure)
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("expected closed circuit after one failure, got %v", err)
+	}
+
+	breaker.failure(failure)
+	if status := breaker.status(); status.Circuit != CircuitOpen || status.OpenUntil == nil {
+		t.Fatalf("expected open circuit after two failures, got %+v", status)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("expected probe after reset timeout, got %v", err)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a single probe while half-open, got %v", err)
+	}
+
+	breaker.failure(failure)
+	if status := breaker.status(); status.Circuit != CircuitOpen {
+		t.Fatalf("expected failed probe to reopen the circuit, got %+v", status)
+	}
+
+	now = now.Add(time.Minute)
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("expected probe after reset timeout, got %v", err)
+	}
+	breaker.success()
+	if status := breaker.status(); status.Circuit != CircuitClosed || status.ConsecutiveFailures != 0 || !status.Healthy() {
+		t.Errorf("expected successful probe to close the circuit, got %+v", status)
+	}
+}
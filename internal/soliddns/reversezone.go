@@ -0,0 +1,232 @@
+package soliddns
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	reverseZoneSuffixIPv4 = ".in-addr.arpa"
+	reverseZoneSuffixIPv6 = ".ip6.arpa"
+)
+
+// ErrNoReverseZone is returned when none of the managed zones covers an address
+var ErrNoReverseZone = errors.New("no managed reverse zone")
+
+// reverseZone describes the address range delegated to a reverse zone
+type reverseZone struct {
+	zone   *ZoneAuth
+	first  netip.Addr // First address covered by the zone
+	last   netip.Addr // Last address covered by the zone
+	bits   int        // Prefix length used to pick the most specific zone
+	labels int        // Number of address labels above the zone apex (IPv4 only)
+}
+
+// reverseZoneResolver maps target addresses to the most specific managed reverse zone
+type reverseZoneResolver struct {
+	zones []reverseZone
+}
+
+// newReverseZoneResolver creates a resolver from a zone list.
+// Zones that are not in-addr.arpa or ip6.arpa zones are ignored.
+// Parameters:
+//   - zones: Zones returned by ZonesList
+//
+// Returns:
+//   - Resolver covering all reverse zones of the list
+func newReverseZoneResolver(zones []*ZoneAuth) *reverseZoneResolver {
+	resolver := &reverseZoneResolver{}
+	for _, zone := range zones {
+		rz, err := parseReverseZone(zone)
+		if err != nil {
+			continue
+		}
+		resolver.zones = append(resolver.zones, rz)
+	}
+	return resolver
+}
+
+// Resolve finds the most specific reverse zone of a smart and view covering an address
+// and the PTR record name to use inside that zone. A scope without view matches the zones of every view.
+// Parameters:
+//   - scope: Smart and view the PTR record is written to
+//   - target: IPv4 or IPv6 address
+//
+// Returns:
+//   - Reverse zone covering the address
+//   - PTR record name within that zone
+//   - ErrNoReverseZone if no managed zone of the scope covers the address, or a parsing error
+func (r *reverseZoneResolver) Resolve(scope recordScope, target string) (*ZoneAuth, string, error) {
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid IP address '%s': %w", target, err)
+	}
+	addr = addr.Unmap()
+
+	var best *reverseZone
+	for i := range r.zones {
+		rz := &r.zones[i]
+		if !scope.selects(rz.zone.scope()) || addr.Is4() != rz.first.Is4() || addr.Less(rz.first) || rz.last.Less(addr) {
+			continue
+		}
+		if best == nil || rz.bits > best.bits {
+			best = rz
+		}
+	}
+	if best == nil {
+		return nil, "", fmt.Errorf("%w for %s in %s", ErrNoReverseZone, addr, scope)
+	}
+
+	return best.zone, best.recordName(addr), nil
+}
+
+// recordName returns the PTR record name of an address inside the zone.
+// Classless IPv4 zones (RFC 2317, RFC 4183) hold the address labels below the
+// delegation label, e.g. "10.0/26.2.0.192.in-addr.arpa".
+func (rz *reverseZone) recordName(addr netip.Addr) string {
+	if addr.Is6() {
+		b := addr.As16()
+		nibbles := make([]string, 0, 2*len(b))
+		for i := len(b) - 1; i >= 0; i-- {
+			nibbles = append(nibbles, strconv.FormatUint(uint64(b[i]&0x0f), 16), strconv.FormatUint(uint64(b[i]>>4), 16))
+		}
+		return strings.Join(nibbles, ".") + reverseZoneSuffixIPv6
+	}
+
+	octets := addr.As4()
+	labels := make([]string, 0, len(octets))
+	for i := len(octets) - 1; i >= rz.labels; i-- {
+		labels = append(labels, strconv.Itoa(int(octets[i])))
+	}
+	return strings.Join(labels, ".") + "." + strings.TrimSuffix(strings.ToLower(rz.zone.Name), ".")
+}
+
+// parseReverseZone computes the address range covered by a reverse zone name
+func parseReverseZone(zone *ZoneAuth) (reverseZone, error) {
+	name := strings.TrimSuffix(strings.ToLower(zone.Name), ".")
+	switch {
+	case strings.HasSuffix(name, reverseZoneSuffixIPv4):
+		return parseIPv4ReverseZone(zone, strings.TrimSuffix(name, reverseZoneSuffixIPv4))
+	case strings.HasSuffix(name, reverseZoneSuffixIPv6):
+		return parseIPv6ReverseZone(zone, strings.TrimSuffix(name, reverseZoneSuffixIPv6))
+	default:
+		return reverseZone{}, fmt.Errorf("'%s' is not a reverse zone", zone.Name)
+	}
+}
+
+// parseIPv4ReverseZone parses octet-aligned zones ("2.0.192") as well as classless
+// delegations using RFC 2317 ("0/26.2.0.192", "0-63.2.0.192", "0-31.2.0.192") or RFC 4183
+// ("0-26.2.0.192", "16-12.172") notation for the most significant label.
+func parseIPv4ReverseZone(zone *ZoneAuth, name string) (reverseZone, error) {
+	labels := strings.Split(name, ".")
+	if len(labels) == 0 || len(labels) > 4 {
+		return reverseZone{}, fmt.Errorf("invalid in-addr.arpa zone '%s'", zone.Name)
+	}
+
+	var first, last [4]byte
+	for i := range last {
+		last[i] = 0xff
+	}
+
+	// Labels are least significant first, so the parent octets follow the first label
+	parents := len(labels)
+	classless := strings.ContainsAny(labels[0], "/-")
+	if classless {
+		parents--
+	}
+	for i := 0; i < parents; i++ {
+		octet, err := strconv.ParseUint(labels[len(labels)-1-i], 10, 8)
+		if err != nil {
+			return reverseZone{}, fmt.Errorf("invalid in-addr.arpa zone '%s': %w", zone.Name, err)
+		}
+		first[i], last[i] = byte(octet), byte(octet)
+	}
+
+	rz := reverseZone{zone: zone, bits: 8 * parents, labels: parents}
+	if classless {
+		start, end, prefix, err := parseClasslessLabel(labels[0], parents)
+		if err != nil {
+			return reverseZone{}, fmt.Errorf("invalid in-addr.arpa zone '%s': %w", zone.Name, err)
+		}
+		first[parents], last[parents] = start, end
+		rz.bits = prefix
+	}
+
+	rz.first, rz.last = netip.AddrFrom4(first), netip.AddrFrom4(last)
+	return rz, nil
+}
+
+// parseClasslessLabel parses the delegation label of a classless in-addr.arpa zone.
+// "A-B" is read as an RFC 2317 octet range when it spans an aligned block ("0-31", "64-127"),
+// otherwise as an RFC 4183 network and prefix length when B is a valid prefix length for the
+// label position and A is aligned to it ("0-26", "16-12"). "A/B" always denotes a network and
+// its prefix length; any other "A-B" is an octet range.
+// Parameters:
+//   - label: Delegation label, e.g. "0/26", "0-26" or "64-127"
+//   - parents: Number of octet-aligned labels above the delegation label
+//
+// Returns:
+//   - First and last octet value covered at the label position
+//   - Prefix length of the delegation
+//   - Error if the label is malformed
+func parseClasslessLabel(label string, parents int) (byte, byte, int, error) {
+	sep := strings.IndexAny(label, "/-")
+	a, errA := strconv.ParseUint(label[:sep], 10, 8)
+	b, errB := strconv.ParseUint(label[sep+1:], 10, 8)
+	if errA != nil || errB != nil {
+		return 0, 0, 0, fmt.Errorf("invalid classless label '%s'", label)
+	}
+
+	base := 8 * parents
+	if label[sep] == '-' && b >= a {
+		if size := b - a + 1; size&(size-1) == 0 && a&(size-1) == 0 {
+			return byte(a), byte(b), base + 8 - bits.TrailingZeros64(size), nil
+		}
+	}
+	if b > uint64(base) && b <= uint64(base+8) {
+		hostBits := base + 8 - int(b)
+		if a&(1<<hostBits-1) == 0 {
+			return byte(a), byte(a | (1<<hostBits - 1)), int(b), nil
+		}
+	}
+	if label[sep] == '/' {
+		return 0, 0, 0, fmt.Errorf("invalid prefix length in classless label '%s'", label)
+	}
+
+	if b < a {
+		return 0, 0, 0, fmt.Errorf("invalid range in classless label '%s'", label)
+	}
+	return byte(a), byte(b), base + 8 - bits.Len8(byte(b-a)), nil
+}
+
+// parseIPv6ReverseZone parses nibble-aligned ip6.arpa zones, e.g. "8.b.d.0.1.0.0.2"
+func parseIPv6ReverseZone(zone *ZoneAuth, name string) (reverseZone, error) {
+	labels := strings.Split(name, ".")
+	if len(labels) == 0 || len(labels) > 32 {
+		return reverseZone{}, fmt.Errorf("invalid ip6.arpa zone '%s'", zone.Name)
+	}
+
+	var first [16]byte
+	for i := 0; i < len(labels); i++ {
+		nibble, err := strconv.ParseUint(labels[len(labels)-1-i], 16, 4)
+		if err != nil || len(labels[len(labels)-1-i]) != 1 {
+			return reverseZone{}, fmt.Errorf("invalid ip6.arpa zone '%s'", zone.Name)
+		}
+		if i%2 == 0 {
+			first[i/2] |= byte(nibble) << 4
+		} else {
+			first[i/2] |= byte(nibble)
+		}
+	}
+
+	prefix := 4 * len(labels)
+	last := first
+	for i := prefix; i < 128; i++ {
+		last[i/8] |= 1 << (7 - i%8)
+	}
+	return reverseZone{zone: zone, first: netip.AddrFrom16(first), last: netip.AddrFrom16(last), bits: prefix}, nil
+}
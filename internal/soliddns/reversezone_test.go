@@ -0,0 +1,116 @@
+package soliddns
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReverseZoneResolver(t *testing.T) {
+	resolver := newReverseZoneResolver([]*ZoneAuth{
+		{Name: "example.com", ID: "1"},
+		{Name: "10.in-addr.arpa", ID: "2"},
+		{Name: "168.192.in-addr.arpa", ID: "3"},
+		{Name: "2.0.192.in-addr.arpa", ID: "4"},
+		{Name: "0/26.2.0.192.in-addr.arpa", ID: "5"},
+		{Name: "64-127.2.0.192.in-addr.arpa", ID: "6"},
+		{Name: "128-26.2.0.192.in-addr.arpa", ID: "7"},
+		{Name: "16-12.172.in-addr.arpa", ID: "8"},
+		{Name: "8.b.d.0.1.0.0.2.ip6.arpa.", ID: "9"},
+		{Name: "1.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", ID: "10"},
+		{Name: "invalid.in-addr.arpa", ID: "11"},
+		{Name: "100.51.198.in-addr.arpa", ID: "12"},
+		{Name: "0-31.100.51.198.in-addr.arpa", ID: "13"},
+		{Name: "32-27.100.51.198.in-addr.arpa", ID: "14"},
+	})
+
+	testCases := []struct {
+		target       string
+		expectedZone string
+		expectedName string
+		noZone       bool
+	}{
+		{target: "10.1.2.3", expectedZone: "2", expectedName: "3.2.1.10.in-addr.arpa"},
+		{target: "192.168.1.20", expectedZone: "3", expectedName: "20.1.168.192.in-addr.arpa"},
+		{target: "192.0.2.10", expectedZone: "5", expectedName: "10.0/26.2.0.192.in-addr.arpa"},
+		{target: "192.0.2.100", expectedZone: "6", expectedName: "100.64-127.2.0.192.in-addr.arpa"},
+		{target: "192.0.2.130", expectedZone: "7", expectedName: "130.128-26.2.0.192.in-addr.arpa"},
+		{target: "192.0.2.200", expectedZone: "4", expectedName: "200.2.0.192.in-addr.arpa"},
+		{target: "172.20.5.10", expectedZone: "8", expectedName: "10.5.20.16-12.172.in-addr.arpa"},
+		{target: "172.32.5.10", noZone: true},
+		{target: "198.51.100.1", expectedZone: "13", expectedName: "1.0-31.100.51.198.in-addr.arpa"},
+		{target: "198.51.100.20", expectedZone: "13", expectedName: "20.0-31.100.51.198.in-addr.arpa"},
+		{target: "198.51.100.31", expectedZone: "13", expectedName: "31.0-31.100.51.198.in-addr.arpa"},
+		{target: "198.51.100.40", expectedZone: "14", expectedName: "40.32-27.100.51.198.in-addr.arpa"},
+		{target: "198.51.100.64", expectedZone: "12", expectedName: "64.100.51.198.in-addr.arpa"},
+		{target: "2001:db8::1", expectedZone: "9", expectedName: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+		{target: "2001:db8:1::1", expectedZone: "10", expectedName: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+		{target: "2001:db9::1", noZone: true},
+	}
+
+	for _, tc := range testCases {
+		zone, name, err := resolver.Resolve(recordScope{}, tc.target)
+		if tc.noZone {
+			if !errors.Is(err, ErrNoReverseZone) {
+				t.Errorf("Resolve(%s): expected ErrNoReverseZone, got %v", tc.target, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Resolve(%s): unexpected error: %v", tc.target, err)
+			continue
+		}
+		if zone.ID != tc.expectedZone {
+			t.Errorf("Resolve(%s): expected zone %s, got %s (%s)", tc.target, tc.expectedZone, zone.ID, zone.Name)
+		}
+		if name != tc.expectedName {
+			t.Errorf("Resolve(%s): expected name %s, got %s", tc.target, tc.expectedName, name)
+		}
+	}
+}
+
+func TestReverseZoneResolverInvalidTarget(t *testing.T) {
+	resolver := newReverseZoneResolver(nil)
+	if _, _, err := resolver.Resolve(recordScope{}, "not-an-ip"); err == nil || errors.Is(err, ErrNoReverseZone) {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestReverseZoneResolverScopes(t *testing.T) {
+	internal := recordScope{smart: "smart", view: "internal"}
+	external := recordScope{smart: "smart", view: "external"}
+	resolver := newReverseZoneResolver([]*ZoneAuth{
+		{Name: "2.0.192.in-addr.arpa", ID: "1", Smart: "smart", View: "internal"},
+		{Name: "0/26.2.0.192.in-addr.arpa", ID: "2", Smart: "smart", View: "external"},
+		{Name: "10.in-addr.arpa", ID: "3", Smart: "other-smart", View: "internal"},
+	})
+
+	testCases := []struct {
+		scope        recordScope
+		target       string
+		expectedZone string
+		noZone       bool
+	}{
+		// The more specific zone of another view is ignored
+		{scope: internal, target: "192.0.2.10", expectedZone: "1"},
+		{scope: external, target: "192.0.2.10", expectedZone: "2"},
+		{scope: external, target: "192.0.2.100", noZone: true},
+		{scope: internal, target: "10.0.0.1", noZone: true},
+		{scope: recordScope{smart: "other-smart", view: "internal"}, target: "10.0.0.1", expectedZone: "3"},
+		// Without a view, the zones of every view of the smart are used
+		{scope: recordScope{smart: "smart"}, target: "192.0.2.10", expectedZone: "2"},
+		{scope: recordScope{smart: "other-smart"}, target: "10.0.0.1", expectedZone: "3"},
+	}
+
+	for _, tc := range testCases {
+		zone, _, err := resolver.Resolve(tc.scope, tc.target)
+		if tc.noZone {
+			if !errors.Is(err, ErrNoReverseZone) {
+				t.Errorf("Resolve(%s, %s): expected ErrNoReverseZone, got %v", tc.scope, tc.target, err)
+			}
+			continue
+		}
+		if err != nil || zone.ID != tc.expectedZone {
+			t.Errorf("Resolve(%s, %s): expected zone %s, got %v (%v)", tc.scope, tc.target, tc.expectedZone, zone, err)
+		}
+	}
+}
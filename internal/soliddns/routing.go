@@ -0,0 +1,179 @@
+package soliddns
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	defaultPrivateView = "internal" // View of private addresses if it is a default view
+	defaultPublicView  = "external" // View of public addresses if it is a default view
+	everyDefaultView   = "*"        // Publishes addresses in every default view
+)
+
+// viewRoute sends the addresses of a range to a DNS view
+type viewRoute struct {
+	prefix netip.Prefix
+	view   string
+}
+
+// viewRouter picks the view of A and AAAA targets by address range
+type viewRouter struct {
+	routes  []viewRoute // Configured ranges, most specific first
+	private string      // View of private addresses outside all ranges (empty for every default view)
+	public  string      // View of public addresses outside all ranges (empty for every default view)
+}
+
+// newViewRouter builds the address range to view mapping.
+// Private addresses (RFC 1918 and RFC 4193) go to the internal view and public addresses to the external view
+// whenever these are default views, so split-horizon setups need no configuration. EIP_VIEW_CIDRS ranges take
+// precedence, and EIP_VIEW_DEFAULT_PRIVATE and EIP_VIEW_DEFAULT_PUBLIC pick other views or "*" for every default view.
+// Parameters:
+//   - config: Configuration with the ranges, the private and public views and the default and allowed views
+//
+// Returns:
+//   - Router matching the most specific range first, nil if no address is routed
+//   - Error if an entry is invalid or a view is not allowed
+func newViewRouter(config *EfficientIPConfig) (*viewRouter, error) {
+	router := &viewRouter{}
+	var err error
+	if router.private, err = addressView(config, config.ViewDefaultPrivate, defaultPrivateView); err != nil {
+		return nil, fmt.Errorf("invalid default private view: %w", err)
+	}
+	if router.public, err = addressView(config, config.ViewDefaultPublic, defaultPublicView); err != nil {
+		return nil, fmt.Errorf("invalid default public view: %w", err)
+	}
+
+	allowedViews := config.allowedViews()
+	for _, entry := range config.ViewCIDRs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cidr, view, found := strings.Cut(entry, "=")
+		cidr, view = strings.TrimSpace(cidr), strings.TrimSpace(view)
+		if !found || view == "" {
+			return nil, fmt.Errorf("invalid view route '%s' (expected <cidr>=<view>)", entry)
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view route '%s': %w", entry, err)
+		}
+		if !slices.Contains(allowedViews, view) {
+			return nil, fmt.Errorf("invalid view route '%s': view '%s' is not allowed", entry, view)
+		}
+		router.routes = append(router.routes, viewRoute{prefix: prefix.Masked(), view: view})
+	}
+	if len(router.routes) == 0 && router.private == "" && router.public == "" {
+		return nil, nil
+	}
+
+	slices.SortStableFunc(router.routes, func(a, b viewRoute) int {
+		return b.prefix.Bits() - a.prefix.Bits()
+	})
+	return router, nil
+}
+
+// addressView resolves the view of private or public addresses.
+// Parameters:
+//   - config: Configuration with the default and allowed views
+//   - view: Configured view, "*" for every default view or empty for the built-in one
+//   - builtin: View used if it is one of several default views and none is configured
+//
+// Returns:
+//   - View of the addresses, empty for every default view
+//   - Error if the configured view is not allowed
+func addressView(config *EfficientIPConfig, view, builtin string) (string, error) {
+	switch view = strings.TrimSpace(view); {
+	case view == everyDefaultView:
+		return "", nil
+	case view == "":
+		if views := config.views(); len(views) > 1 && slices.Contains(views, builtin) {
+			return builtin, nil
+		}
+		return "", nil
+	case !slices.Contains(config.allowedViews(), view):
+		return "", fmt.Errorf("view '%s' is not allowed", view)
+	}
+	return view, nil
+}
+
+// route returns the view of a target.
+// Only A and AAAA targets are routed: targets inside a configured range go to its view,
+// other private and public addresses to the private and public view, if any.
+func (r *viewRouter) route(recordType, target string) (string, bool) {
+	if r == nil || (recordType != endpoint.RecordTypeA && recordType != endpoint.RecordTypeAAAA) {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+	for _, route := range r.routes {
+		if route.prefix.Contains(addr) {
+			return route.view, true
+		}
+	}
+	switch {
+	case addr.IsPrivate() && r.private != "":
+		return r.private, true
+	case !addr.IsPrivate() && addr.IsGlobalUnicast() && r.public != "":
+		return r.public, true
+	}
+	return "", false
+}
+
+// routeEndpoint splits an A or AAAA endpoint into one endpoint per view its targets are routed to.
+// Targets that are not routed go to every default view. Endpoints selecting a view
+// themselves, other record types and all endpoints without routes are returned unchanged.
+// Parameters:
+//   - ep: Endpoint to write
+//
+// Returns:
+//   - Endpoints selecting a single view each, in order of first appearance of the view
+func (p *Provider) routeEndpoint(ep *endpoint.Endpoint) []*endpoint.Endpoint {
+	if p.router == nil || (ep.RecordType != endpoint.RecordTypeA && ep.RecordType != endpoint.RecordTypeAAAA) {
+		return []*endpoint.Endpoint{ep}
+	}
+	if _, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipView); found {
+		return []*endpoint.Endpoint{ep}
+	}
+
+	var views []string
+	targets := make(map[string][]string)
+	add := func(view, target string) {
+		if _, found := targets[view]; !found {
+			views = append(views, view)
+		}
+		targets[view] = append(targets[view], target)
+	}
+	for _, target := range ep.Targets {
+		if view, found := p.router.route(ep.RecordType, target); found {
+			add(view, target)
+			continue
+		}
+		for _, view := range p.config.views() {
+			add(view, target)
+		}
+	}
+
+	parts := make([]*endpoint.Endpoint, 0, len(views))
+	for _, view := range views {
+		part := withTargets(ep, targets[view])
+		part.ProviderSpecific = slices.Clone(ep.ProviderSpecific)
+		setProviderSpecific(part, providerSpecificEfficientipView, view)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// viewOf returns the view property of an endpoint
+func viewOf(ep *endpoint.Endpoint) string {
+	view, _ := ep.GetProviderSpecificProperty(providerSpecificEfficientipView)
+	return view
+}
@@ -0,0 +1,316 @@
+package soliddns
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestNewViewRouter(t *testing.T) {
+	testCases := []struct {
+		name            string
+		config          EfficientIPConfig
+		expectedPrivate string
+		expectedPublic  string
+		noRouter        bool
+		err             bool
+	}{
+		{name: "built-in views", config: EfficientIPConfig{DnsViews: []string{"internal", "external"}}, expectedPrivate: "internal", expectedPublic: "external"},
+		{name: "single default view", config: EfficientIPConfig{DnsView: "internal", AllowedViews: []string{"external"}}, noRouter: true},
+		{name: "no built-in view", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}}, noRouter: true},
+		{name: "every default view", config: EfficientIPConfig{DnsViews: []string{"internal", "external"}, ViewDefaultPublic: "*"}, expectedPrivate: "internal"},
+		{name: "configured views", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}, AllowedViews: []string{"dmz"}, ViewDefaultPrivate: "lan", ViewDefaultPublic: " dmz "}, expectedPrivate: "lan", expectedPublic: "dmz"},
+		{name: "private view not allowed", config: EfficientIPConfig{DnsViews: []string{"internal", "external"}, ViewDefaultPrivate: "dmz"}, err: true},
+		{name: "public view not allowed", config: EfficientIPConfig{DnsViews: []string{"internal", "external"}, ViewDefaultPublic: "dmz"}, err: true},
+		{name: "ranges", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}, ViewCIDRs: []string{"10.0.0.0/8=lan", " 0.0.0.0/0 = wan ", "fd00::/8=lan", " "}}},
+		{name: "missing view", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}, ViewCIDRs: []string{"10.0.0.0/8="}}, err: true},
+		{name: "missing separator", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}, ViewCIDRs: []string{"10.0.0.0/8"}}, err: true},
+		{name: "bad cidr", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}, ViewCIDRs: []string{"10.0.0.0/33=lan"}}, err: true},
+		{name: "range view not allowed", config: EfficientIPConfig{DnsViews: []string{"lan", "wan"}, ViewCIDRs: []string{"10.0.0.0/8=dmz"}}, err: true},
+	}
+
+	for _, tc := range testCases {
+		router, err := newViewRouter(&tc.config)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if tc.noRouter {
+			if router != nil {
+				t.Errorf("%s: expected no router, got %+v", tc.name, router)
+			}
+			continue
+		}
+		if router == nil || router.private != tc.expectedPrivate || router.public != tc.expectedPublic {
+			t.Errorf("%s: expected private view %q and public view %q, got %+v", tc.name, tc.expectedPrivate, tc.expectedPublic, router)
+		}
+	}
+}
+
+func TestViewRouterRoute(t *testing.T) {
+	router, err := newViewRouter(&EfficientIPConfig{
+		DnsViews:  []string{"internal", "external"},
+		ViewCIDRs: []string{"10.1.0.0/16=external", "203.0.113.0/24=internal"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		recordType string
+		target     string
+		view       string
+		found      bool
+	}{
+		// Private addresses go to the internal view, public ones to the external view
+		{recordType: endpoint.RecordTypeA, target: "10.2.3.4", view: "internal", found: true},
+		{recordType: endpoint.RecordTypeA, target: "172.16.0.1", view: "internal", found: true},
+		{recordType: endpoint.RecordTypeA, target: "192.168.1.1", view: "internal", found: true},
+		{recordType: endpoint.RecordTypeA, target: "::ffff:10.2.3.4", view: "internal", found: true},
+		{recordType: endpoint.RecordTypeAAAA, target: "fd12::1", view: "internal", found: true},
+		{recordType: endpoint.RecordTypeA, target: "192.0.2.1", view: "external", found: true},
+		{recordType: endpoint.RecordTypeAAAA, target: "2001:db8::1", view: "external", found: true},
+		// Configured ranges take precedence
+		{recordType: endpoint.RecordTypeA, target: "10.1.2.3", view: "external", found: true},
+		{recordType: endpoint.RecordTypeA, target: "203.0.113.5", view: "internal", found: true},
+		// Neither private nor public
+		{recordType: endpoint.RecordTypeA, target: "127.0.0.1"},
+		{recordType: endpoint.RecordTypeAAAA, target: "fe80::1"},
+		{recordType: endpoint.RecordTypeCNAME, target: "10.2.3.4"},
+		{recordType: endpoint.RecordTypeA, target: "not-an-address"},
+	}
+
+	for _, tc := range testCases {
+		view, found := router.route(tc.recordType, tc.target)
+		if view != tc.view || found != tc.found {
+			t.Errorf("%s %s: expected %q %t, got %q %t", tc.recordType, tc.target, tc.view, tc.found, view, found)
+		}
+	}
+
+	var none *viewRouter
+	if _, found := none.route(endpoint.RecordTypeA, "10.2.3.4"); found {
+		t.Error("expected a nil router not to route")
+	}
+
+	// Addresses of "*" go to every default view, only configured ranges are still routed
+	router, err = newViewRouter(&EfficientIPConfig{
+		DnsViews:           []string{"internal", "external"},
+		ViewCIDRs:          []string{"10.0.0.0/8=internal"},
+		ViewDefaultPrivate: "*",
+		ViewDefaultPublic:  "*",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view, found := router.route(endpoint.RecordTypeA, "10.2.3.4"); view != "internal" || !found {
+		t.Errorf("expected 10.2.3.4 to go to the internal view, got %q %t", view, found)
+	}
+	for _, target := range []string{"172.16.0.1", "192.0.2.1"} {
+		if view, found := router.route(endpoint.RecordTypeA, target); found {
+			t.Errorf("expected %s not to be routed, got %q", target, view)
+		}
+	}
+}
+
+func newRoutedProvider(t *testing.T, client EfficientIPClient) *Provider {
+	t.Helper()
+	return newRoutedProviderWithPublicView(t, client, "")
+}
+
+func newRoutedProviderWithPublicView(t *testing.T, client EfficientIPClient, public string) *Provider {
+	t.Helper()
+	config := &EfficientIPConfig{DnsSmart: "dns-smart", DnsViews: []string{"internal", "external"}, ViewDefaultPublic: public}
+	router, err := newViewRouter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Provider{client: client, config: config, router: router, cache: newRecordCache(0, 0)}
+}
+
+func TestRouteEndpoint(t *testing.T) {
+	p := newRoutedProvider(t, &fakeClient{})
+
+	ep := endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.1", "192.0.2.1", "192.0.2.2")
+	parts := p.routeEndpoint(ep)
+	expected := map[string][]string{
+		"internal": {"10.0.0.1"},
+		"external": {"192.0.2.1", "192.0.2.2"},
+	}
+	if len(parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %v", len(expected), parts)
+	}
+	for _, part := range parts {
+		targets := expected[viewOf(part)]
+		if _, removed, added := diffTargets(part.RecordType, part.Targets, targets); len(removed) > 0 || len(added) > 0 {
+			t.Errorf("view %q: expected %v, got %v", viewOf(part), targets, part.Targets)
+		}
+	}
+	if len(ep.ProviderSpecific) != 0 || len(ep.Targets) != 3 {
+		t.Errorf("expected the endpoint to be left untouched, got %v %v", ep, ep.ProviderSpecific)
+	}
+
+	explicit := endpoint.NewEndpoint("app.example.com", endpoint.RecordTypeA, "10.0.0.1").
+		WithProviderSpecific(providerSpecificEfficientipView, "external")
+	if parts := p.routeEndpoint(explicit); len(parts) != 1 || parts[0] != explicit {
+		t.Errorf("expected an endpoint with a view to be kept, got %v", parts)
+	}
+
+	cname := endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeCNAME, "app.example.com")
+	if parts := p.routeEndpoint(cname); len(parts) != 1 || parts[0] != cname {
+		t.Errorf("expected a CNAME to be kept, got %v", parts)
+	}
+
+	// Listed records written that way compare equal to the endpoint, records leaked into the internal view do not
+	records := map[string][]*endpoint.Endpoint{
+		"internal": {
+			endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.1"),
+			endpoint.NewEndpointWithTTL("leaked.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+		},
+		"external": {
+			endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "192.0.2.1", "192.0.2.2"),
+			endpoint.NewEndpointWithTTL("leaked.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+		},
+	}
+	p.client = &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "dns-smart", View: "internal"},
+			{Name: "example.com", ID: "2", Smart: "dns-smart", View: "external"},
+		},
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			return records[zone.View], nil
+		},
+	}
+	endpoints, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(endpoints) != 2 {
+		t.Fatalf("expected the app and leaked records, got %v", endpoints)
+	}
+	if endpoints[0].DNSName != "app.example.com" || len(endpoints[0].Targets) != 3 || len(endpoints[0].ProviderSpecific) != 0 {
+		t.Errorf("expected app.example.com with all targets and no view, got %v %v", endpoints[0], endpoints[0].ProviderSpecific)
+	}
+	if _, drift := endpoints[1].GetProviderSpecificProperty(providerSpecificEfficientipDrift); endpoints[1].DNSName != "leaked.example.com" || !drift {
+		t.Errorf("expected leaked.example.com to be reported as drifted, got %v %v", endpoints[1], endpoints[1].ProviderSpecific)
+	}
+}
+
+func TestRecordsRoutedViews(t *testing.T) {
+	records := map[string][]*endpoint.Endpoint{
+		"internal": {
+			endpoint.NewEndpointWithTTL("mixed.example.com", endpoint.RecordTypeA, 300, "10.0.0.1", "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("private.example.com", endpoint.RecordTypeA, 300, "10.0.0.2"),
+			endpoint.NewEndpointWithTTL("leaked.example.com", endpoint.RecordTypeA, 300, "10.0.0.3"),
+		},
+		"external": {
+			endpoint.NewEndpointWithTTL("mixed.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("leaked.example.com", endpoint.RecordTypeA, 300, "10.0.0.3"),
+		},
+	}
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "dns-smart", View: "internal"},
+			{Name: "example.com", ID: "2", Smart: "dns-smart", View: "external"},
+		},
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			return records[zone.View], nil
+		},
+	}
+	// Public targets are published in every default view, the internal one included
+	p := newRoutedProviderWithPublicView(t, client, "*")
+	p.config.ListConcurrency = 2
+
+	endpoints, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(endpoints) != 3 {
+		t.Fatalf("expected the mixed, private and leaked records, got %v", endpoints)
+	}
+	if endpoints[0].DNSName != "mixed.example.com" || len(endpoints[0].Targets) != 2 || len(endpoints[0].ProviderSpecific) != 0 {
+		t.Errorf("expected mixed.example.com with both targets and no view, got %v %v", endpoints[0], endpoints[0].ProviderSpecific)
+	}
+	if endpoints[1].DNSName != "private.example.com" || len(endpoints[1].ProviderSpecific) != 0 {
+		t.Errorf("expected private.example.com without view, got %v %v", endpoints[1], endpoints[1].ProviderSpecific)
+	}
+	if _, drift := endpoints[2].GetProviderSpecificProperty(providerSpecificEfficientipDrift); endpoints[2].DNSName != "leaked.example.com" || !drift {
+		t.Errorf("expected leaked.example.com to be reported as drifted, got %v %v", endpoints[2], endpoints[2].ProviderSpecific)
+	}
+
+	// Repairing the leaked record removes the private target from the external view only
+	desired := endpoint.NewEndpointWithTTL("leaked.example.com", endpoint.RecordTypeA, 300, "10.0.0.3")
+	if err := p.UpdateChanges(context.Background(), endpoints[2], desired); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.ops) != 1 || client.ops[0] != "delete leaked.example.com A" || viewOf(client.applied[0]) != "external" {
+		t.Errorf("expected the external copy to be deleted, got %v", client.ops)
+	}
+}
+
+func TestRecordsRoutedViewsOfOtherSmart(t *testing.T) {
+	records := map[string][]*endpoint.Endpoint{
+		"internal": {endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.1")},
+		"external": {endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "192.0.2.1")},
+	}
+	client := &fakeClient{
+		zones: []*ZoneAuth{
+			{Name: "example.com", ID: "1", Smart: "other-smart", View: "internal"},
+			{Name: "example.com", ID: "2", Smart: "other-smart", View: "external"},
+		},
+		recordList: func(_ context.Context, zone ZoneAuth) ([]*endpoint.Endpoint, error) {
+			return records[zone.View], nil
+		},
+	}
+	p := newRoutedProvider(t, client)
+	p.config.AllowedSmarts = []string{"other-smart"}
+
+	// Records routed within another smart compare equal to the endpoint selecting that smart
+	endpoints, err := p.Records(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(endpoints) != 1 {
+		t.Fatalf("expected only app.example.com, got %v", endpoints)
+	}
+	smart, _ := endpoints[0].GetProviderSpecificProperty(providerSpecificEfficientipSmart)
+	if _, drift := endpoints[0].GetProviderSpecificProperty(providerSpecificEfficientipDrift); smart != "other-smart" || drift || viewOf(endpoints[0]) != "" || len(endpoints[0].Targets) != 2 {
+		t.Errorf("expected app.example.com in other-smart with both targets, got %v %v", endpoints[0], endpoints[0].ProviderSpecific)
+	}
+
+	// and are written to the views of that smart
+	ep := endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.1", "192.0.2.1").
+		WithProviderSpecific(providerSpecificEfficientipSmart, "other-smart")
+	for _, part := range p.routeEndpoint(ep) {
+		if scope := p.scopeOf(part); scope.smart != "other-smart" {
+			t.Errorf("expected every part to be written to other-smart, got %s", scope)
+		}
+	}
+}
+
+func TestUpdateChangesRoutedViews(t *testing.T) {
+	client := &fakeClient{}
+	p := newRoutedProvider(t, client)
+
+	current := endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.1", "192.0.2.1")
+	desired := endpoint.NewEndpointWithTTL("app.example.com", endpoint.RecordTypeA, 300, "10.0.0.2")
+	if err := p.UpdateChanges(context.Background(), current, desired); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"update app.example.com A", "delete app.example.com A"}
+	if len(client.ops) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, client.ops)
+	}
+	for i := range expected {
+		if client.ops[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, client.ops)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package soliddns
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsVersions maps EIP_TLS_MIN_VERSION values to TLS protocol versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig builds the TLS configuration of the SOLIDserver connection.
+// Parameters:
+//   - config: Provider configuration with the TLS options
+//
+// Returns:
+//   - TLS configuration for the HTTP transport
+//   - Error if a file cannot be loaded or an option is invalid
+func newTLSConfig(config *EfficientIPConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: !config.SSLVerify,
+		ServerName:         config.TLSServerName,
+	}
+
+	if config.TLSMinVersion != "" {
+		version, found := tlsVersions[config.TLSMinVersion]
+		if !found {
+			return nil, fmt.Errorf("invalid minimum TLS version '%s' (expected 1.0, 1.1, 1.2 or 1.3)", config.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	// The CA bundle replaces the system roots so only the internal CA is trusted
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		if config.ClientCertFile == "" || config.ClientKeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be configured together")
+		}
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,97 @@
+package soliddns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key as PEM files
+func writeTestCertificate(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caFile, caKeyFile := writeTestCertificate(t, dir, "ca")
+	certFile, keyFile := writeTestCertificate(t, dir, "client")
+	emptyFile := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(emptyFile, nil, 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	config := &EfficientIPConfig{
+		SSLVerify:      true,
+		CAFile:         caFile,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+		TLSServerName:  "sds.example.com",
+		TLSMinVersion:  "1.3",
+	}
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 ||
+		tlsConfig.ServerName != "sds.example.com" || tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("unexpected TLS configuration: %+v", tlsConfig)
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(c *EfficientIPConfig)
+	}{
+		{name: "unknown TLS version", modify: func(c *EfficientIPConfig) { c.TLSMinVersion = "1.4" }},
+		{name: "missing CA bundle", modify: func(c *EfficientIPConfig) { c.CAFile = filepath.Join(dir, "missing.pem") }},
+		{name: "empty CA bundle", modify: func(c *EfficientIPConfig) { c.CAFile = emptyFile }},
+		{name: "certificate without key", modify: func(c *EfficientIPConfig) { c.ClientKeyFile = "" }},
+		{name: "mismatched key", modify: func(c *EfficientIPConfig) { c.ClientKeyFile = caKeyFile }},
+	}
+	for _, tc := range testCases {
+		invalid := *config
+		tc.modify(&invalid)
+		if _, err := newTLSConfig(&invalid); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
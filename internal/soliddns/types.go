@@ -4,12 +4,17 @@ import eip "github.com/efficientip-labs/solidserver-go-client/sdsclient"
 
 const (
 	providerSpecificEfficientipPtrRecord = "efficientip-ptr-record-exists"
+	providerSpecificEfficientipView      = "webhook/efficientip-view"
+	providerSpecificEfficientipSmart     = "webhook/efficientip-smart"
+	providerSpecificEfficientipDrift     = "webhook/efficientip-drift"
 )
 
 type ZoneAuth struct {
-	Name string
-	Type string
-	ID   string
+	Name  string
+	Type  string
+	ID    string
+	Smart string // Smart the zone was listed from
+	View  string // View the zone lives in, as listed (empty if the smart has no views)
 }
 
 func NewZoneAuth(zone eip.DataInnerDnsZoneData) *ZoneAuth {
@@ -17,5 +22,11 @@ func NewZoneAuth(zone eip.DataInnerDnsZoneData) *ZoneAuth {
 		Name: zone.GetZoneName(),
 		Type: zone.GetZoneType(),
 		ID:   zone.GetZoneId(),
+		View: zone.GetViewName(),
 	}
 }
+
+// scope returns the smart and view the zone lives in
+func (z *ZoneAuth) scope() recordScope {
+	return recordScope{smart: z.Smart, view: z.View}
+}
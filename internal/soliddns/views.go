@@ -0,0 +1,381 @@
+package soliddns
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// recordScope is the DNS smart and view a record lives in
+type recordScope struct {
+	smart string
+	view  string // Empty if no view is configured
+}
+
+// key returns the index and cache key of the scope
+func (s recordScope) key() string {
+	return s.smart + "|" + s.view
+}
+
+// selects reports whether writes to the scope reach another scope.
+// A scope without view selects every view of its smart, as SOLIDserver picks the view of the zone itself.
+func (s recordScope) selects(other recordScope) bool {
+	return s.smart == other.smart && (s.view == "" || s.view == other.view)
+}
+
+// String returns the scope as smart/view
+func (s recordScope) String() string {
+	if s.view == "" {
+		return s.smart
+	}
+	return s.smart + "/" + s.view
+}
+
+// viewCopies holds the copies of one record found in the listed scopes
+type viewCopies struct {
+	first   *endpoint.Endpoint
+	scopes  []recordScope // Scopes holding a copy, in listing order
+	byScope map[recordScope]*endpoint.Endpoint
+}
+
+// mergeViews combines the records listed from the zones of every smart and view.
+// A record held with the same TTL by exactly the scopes writing it to the smart it was first listed in would
+// produce is reported once without view property, and with a smart property outside the default smart: every
+// default view of the smart holds the same targets, except for A and AAAA targets routed to a single view by
+// address. A record held by a single other scope is reported with the properties
+// selecting it, so endpoints targeting that view or smart compare equal and endpoints missing from
+// some default views get moved back to all of them. Any other combination is drift: it is logged and
+// reported with the targets and TTL of the first default view holding it plus the drift property, so
+// it never compares equal and external-dns updates or deletes it. The copies are returned to repair it view by view.
+// Parameters:
+//   - config: Configuration with the default smart and views
+//   - router: Views of A and AAAA targets by address range (may be nil)
+//   - zones: Listed zones, each tagged with its smart and view
+//   - zoneRecords: Records of each zone, in zone order
+//
+// Returns:
+//   - Merged records, in listing order
+//   - Copies of the drifted records, by update key
+func mergeViews(config *EfficientIPConfig, router *viewRouter, zones []*ZoneAuth, zoneRecords [][]*endpoint.Endpoint) ([]*endpoint.Endpoint, map[string]*viewCopies) {
+	var order []string
+	copies := make(map[string]*viewCopies)
+	for i, records := range zoneRecords {
+		scope := zones[i].scope()
+		for _, ep := range records {
+			key := updateKey(ep)
+			c, found := copies[key]
+			if !found {
+				c = &viewCopies{first: ep, byScope: make(map[recordScope]*endpoint.Endpoint)}
+				copies[key] = c
+				order = append(order, key)
+			}
+			if _, found := c.byScope[scope]; !found {
+				c.scopes = append(c.scopes, scope)
+				c.byScope[scope] = ep
+			}
+		}
+	}
+
+	var endpoints []*endpoint.Endpoint
+	drifted := make(map[string]*viewCopies)
+	for _, key := range order {
+		c := copies[key]
+		smart := c.scopes[0].smart
+		defaults := c.defaults(config)
+		if smart == config.DnsSmart {
+			smart = ""
+		}
+		targets := c.targets()
+		switch {
+		case c.consistent(c.expected(targets, defaults, router)):
+			endpoints = append(endpoints, withScopeProperties(withTargets(c.first, targets), smart, ""))
+		case len(c.scopes) == 1:
+			view := c.scopes[0].view
+			if len(defaults) == 1 && defaults[0].view == view {
+				view = ""
+			}
+			endpoints = append(endpoints, withScopeProperties(c.first, smart, view))
+		default:
+			log.Warnf("Reporting %s record %s for repair, it differs between views: %s", c.first.RecordType, c.first.DNSName, c.drift(defaults))
+			drifted[key] = c
+			ep := withScopeProperties(c.reference(defaults), smart, "")
+			ep.WithProviderSpecific(providerSpecificEfficientipDrift, "true")
+			endpoints = append(endpoints, ep)
+		}
+	}
+	return endpoints, drifted
+}
+
+// defaults returns the scopes writing the record to the smart it was first listed in would produce.
+// Without a configured view these are the views of that smart holding a copy, as records are written
+// to whichever view holds their zone.
+func (c *viewCopies) defaults(config *EfficientIPConfig) []recordScope {
+	smart := c.scopes[0].smart
+	defaults := config.defaultScopes(smart)
+	if len(defaults) > 1 || defaults[0].view != "" {
+		return defaults
+	}
+
+	var listed []recordScope
+	for _, scope := range c.scopes {
+		if defaults[0].selects(scope) {
+			listed = append(listed, scope)
+		}
+	}
+	return listed
+}
+
+// reference returns the copy held by the first default scope, or the first copy if no default scope holds one
+func (c *viewCopies) reference(defaults []recordScope) *endpoint.Endpoint {
+	for _, scope := range defaults {
+		if ep, found := c.byScope[scope]; found {
+			return ep
+		}
+	}
+	return c.first
+}
+
+// inScope returns the copy held by a scope, carrying the properties selecting exactly that scope
+func (c *viewCopies) inScope(scope recordScope) *endpoint.Endpoint {
+	return withScopeProperties(c.byScope[scope], scope.smart, scope.view)
+}
+
+// targets returns the targets of all copies, without duplicates
+func (c *viewCopies) targets() []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, scope := range c.scopes {
+		ep := c.byScope[scope]
+		for _, target := range ep.Targets {
+			if key := targetKey(ep.DNSName, ep.RecordType, target); !seen[key] {
+				seen[key] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// expected returns the targets each scope holds when the record is written to its default scopes.
+// Routed targets stay in the smart the record was first listed in, like the endpoints they are written from.
+func (c *viewCopies) expected(targets []string, defaults []recordScope, router *viewRouter) map[recordScope][]string {
+	expected := make(map[recordScope][]string, len(defaults))
+	for _, target := range targets {
+		if view, found := router.route(c.first.RecordType, target); found {
+			scope := recordScope{smart: c.scopes[0].smart, view: view}
+			expected[scope] = append(expected[scope], target)
+			continue
+		}
+		for _, scope := range defaults {
+			expected[scope] = append(expected[scope], target)
+		}
+	}
+	return expected
+}
+
+// consistent reports whether exactly the expected scopes hold the record, all with the expected targets and the same TTL
+func (c *viewCopies) consistent(expected map[recordScope][]string) bool {
+	if len(c.scopes) != len(expected) {
+		return false
+	}
+	for scope, targets := range expected {
+		ep, found := c.byScope[scope]
+		if !found || ep.RecordTTL != c.first.RecordTTL {
+			return false
+		}
+		if _, removed, added := diffTargets(ep.RecordType, ep.Targets, targets); len(removed) > 0 || len(added) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// drift describes the copies of the record held by the default and other scopes
+func (c *viewCopies) drift(defaults []recordScope) string {
+	scopes := slices.Clone(defaults)
+	for _, scope := range c.scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	parts := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if ep, found := c.byScope[scope]; found {
+			parts = append(parts, fmt.Sprintf("%s has %v (TTL %d)", scope, ep.Targets, ep.RecordTTL))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s has none", scope))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// driftedCopies returns the copies of a record reported as drifted by the last listing.
+// Returns nil for other records and if the copies are unknown (e.g. after a restart), so they are
+// applied as usual and repaired once listed again.
+func (p *Provider) driftedCopies(ep *endpoint.Endpoint) *viewCopies {
+	if _, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipDrift); !found {
+		return nil
+	}
+	p.driftMu.Lock()
+	defer p.driftMu.Unlock()
+	return p.drifted[updateKey(ep)]
+}
+
+// repairDrift writes a drifted record scope by scope: copies in the scopes of the desired endpoint are
+// updated unless they already match, missing ones are created and copies in any other scope are deleted.
+// Parameters:
+//   - copies: Copies of the record found by the last listing
+//   - desired: Endpoint the record must converge to
+//
+// Returns:
+//   - Error if a scope could not be written
+func (p *Provider) repairDrift(ctx context.Context, copies *viewCopies, desired *endpoint.Endpoint) error {
+	written := make(map[recordScope]bool)
+	for _, part := range p.repairParts(copies, desired) {
+		scope := p.scopeOf(part)
+		written[scope] = true
+		current, found := copies.byScope[scope]
+		switch {
+		case !found:
+			if err := p.createEndpoint(ctx, part); err != nil {
+				return err
+			}
+		case !sameRecord(current, part) || ownershipChanged(current.Labels, part.Labels):
+			if err := p.updateEndpoint(ctx, copies.inScope(scope), part); err != nil {
+				return err
+			}
+		}
+	}
+	for _, scope := range copies.scopes {
+		if written[scope] {
+			continue
+		}
+		if err := p.deleteEndpoint(ctx, copies.inScope(scope)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scopeParts splits an endpoint into one endpoint per scope it is written to, each selecting its view
+func (p *Provider) scopeParts(ep *endpoint.Endpoint) []*endpoint.Endpoint {
+	var parts []*endpoint.Endpoint
+	for _, part := range p.routeEndpoint(ep) {
+		if _, found := part.GetProviderSpecificProperty(providerSpecificEfficientipView); found {
+			parts = append(parts, part)
+			continue
+		}
+		for _, view := range p.config.views() {
+			cp := withTargets(part, part.Targets)
+			cp.ProviderSpecific = slices.Clone(part.ProviderSpecific)
+			if view != "" {
+				setProviderSpecific(cp, providerSpecificEfficientipView, view)
+			}
+			parts = append(parts, cp)
+		}
+	}
+	return parts
+}
+
+// repairParts splits a desired endpoint like scopeParts. Without a configured view, a part is written to every
+// view of its smart holding a copy instead, so zones of the same name in several views are repaired in place.
+func (p *Provider) repairParts(copies *viewCopies, desired *endpoint.Endpoint) []*endpoint.Endpoint {
+	var parts []*endpoint.Endpoint
+	for _, part := range p.scopeParts(desired) {
+		scope := p.scopeOf(part)
+		var views []string
+		for _, listed := range copies.scopes {
+			if scope.view == "" && listed.view != "" && scope.selects(listed) {
+				views = append(views, listed.view)
+			}
+		}
+		if len(views) == 0 {
+			parts = append(parts, part)
+			continue
+		}
+		for _, view := range views {
+			cp := withTargets(part, part.Targets)
+			cp.ProviderSpecific = slices.Clone(part.ProviderSpecific)
+			setProviderSpecific(cp, providerSpecificEfficientipView, view)
+			parts = append(parts, cp)
+		}
+	}
+	return parts
+}
+
+// scopeOf returns the scope an endpoint selecting at most one view is written to
+func (p *Provider) scopeOf(ep *endpoint.Endpoint) recordScope {
+	scope := recordScope{smart: p.config.DnsSmart, view: viewOf(ep)}
+	if smart, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipSmart); found && smart != "" {
+		scope.smart = smart
+	}
+	return scope
+}
+
+// sameRecord reports whether two copies of a record have the same targets and TTL
+func sameRecord(a, b *endpoint.Endpoint) bool {
+	if a.RecordTTL != b.RecordTTL {
+		return false
+	}
+	_, removed, added := diffTargets(a.RecordType, a.Targets, b.Targets)
+	return len(removed) == 0 && len(added) == 0
+}
+
+// withScopeProperties returns a copy of a listed endpoint carrying only the given smart and view properties.
+// Listed endpoints may be cached, so they are never modified.
+func withScopeProperties(ep *endpoint.Endpoint, smart, view string) *endpoint.Endpoint {
+	cp := *ep
+	cp.ProviderSpecific = nil
+	for _, property := range ep.ProviderSpecific {
+		if property.Name != providerSpecificEfficientipSmart && property.Name != providerSpecificEfficientipView {
+			cp.ProviderSpecific = append(cp.ProviderSpecific, property)
+		}
+	}
+	if smart != "" {
+		cp.WithProviderSpecific(providerSpecificEfficientipSmart, smart)
+	}
+	if view != "" {
+		cp.WithProviderSpecific(providerSpecificEfficientipView, view)
+	}
+	return &cp
+}
+
+// adjustScope validates the view and smart properties of an endpoint against the allowed values.
+// Properties naming the defaults are dropped so the endpoint compares equal to its listed records.
+// Parameters:
+//   - ep: Endpoint to adjust
+//
+// Returns:
+//   - Error if the endpoint selects a view or smart that is not allowed
+func (p *Provider) adjustScope(ep *endpoint.Endpoint) error {
+	if view, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipView); found {
+		view = strings.TrimSpace(view)
+		views := p.config.views()
+		switch {
+		case view == "" || (len(views) == 1 && views[0] == view):
+			ep.DeleteProviderSpecificProperty(providerSpecificEfficientipView)
+		case !slices.Contains(p.config.allowedViews(), view):
+			return fmt.Errorf("view '%s' is not allowed", view)
+		default:
+			setProviderSpecific(ep, providerSpecificEfficientipView, view)
+		}
+	}
+
+	if smart, found := ep.GetProviderSpecificProperty(providerSpecificEfficientipSmart); found {
+		smart = strings.TrimSpace(smart)
+		switch {
+		case smart == "" || smart == p.config.DnsSmart:
+			ep.DeleteProviderSpecificProperty(providerSpecificEfficientipSmart)
+		case !slices.Contains(p.config.allowedSmarts(), smart):
+			return fmt.Errorf("smart '%s' is not allowed", smart)
+		default:
+			setProviderSpecific(ep, providerSpecificEfficientipSmart, smart)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package soliddns
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestConfigViews(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   EfficientIPConfig
+		expected []string
+	}{
+		{name: "no view", expected: []string{""}},
+		{name: "single view", config: EfficientIPConfig{DnsView: "internal"}, expected: []string{"internal"}},
+		{name: "view list", config: EfficientIPConfig{DnsView: "ignored", DnsViews: []string{" internal", "external ", "internal", ""}}, expected: []string{"internal", "external"}},
+	}
+
+	for _, tc := range testCases {
+		views := tc.config.views()
+		if len(views) != len(tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, views)
+			continue
+		}
+		for i := range views {
+			if views[i] != tc.expected[i] {
+				t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, views)
+			}
+		}
+	}
+}
+
+func TestMergeViews(t *testing.T) {
+	config := &EfficientIPConfig{DnsSmart: "dns-smart", DnsViews: []string{"internal", "external"}, AllowedSmarts: []string{"other-smart"}}
+	zones := []*ZoneAuth{
+		{Name: "example.com", Smart: "dns-smart", View: "internal"},
+		{Name: "example.com", Smart: "dns-smart", View: "external"},
+		{Name: "example.com", Smart: "other-smart", View: "internal"},
+		{Name: "example.com", Smart: "other-smart", View: "external"},
+	}
+	listed := func(name string, targets ...string) *endpoint.Endpoint {
+		return endpoint.NewEndpointWithTTL(name, endpoint.RecordTypeAAAA, 300, targets...).
+			WithProviderSpecific(providerSpecificEfficientipSmart, "listed").
+			WithProviderSpecific(providerSpecificEfficientipView, "listed")
+	}
+	zoneRecords := [][]*endpoint.Endpoint{
+		{listed("both.example.com", "2001:db8::1"), listed("internal.example.com", "2001:db8::2"), listed("drift.example.com", "2001:db8::3")},
+		{listed("both.example.com", "2001:DB8:0::1"), listed("drift.example.com", "2001:db8::4")},
+		{listed("other.example.com", "2001:db8::5"), listed("smart.example.com", "2001:db8::6")},
+		{listed("smart.example.com", "2001:db8::6")},
+	}
+
+	expected := []struct {
+		name  string
+		smart string
+		view  string
+		drift bool
+	}{
+		{name: "both.example.com"},
+		{name: "internal.example.com", view: "internal"},
+		{name: "drift.example.com", drift: true},
+		{name: "other.example.com", smart: "other-smart", view: "internal"},
+		{name: "smart.example.com", smart: "other-smart"},
+	}
+
+	endpoints, drifted := mergeViews(config, nil, zones, zoneRecords)
+	if len(endpoints) != len(expected) {
+		t.Fatalf("expected %d endpoints, got %v", len(expected), endpoints)
+	}
+	for i, e := range expected {
+		ep := endpoints[i]
+		smart, _ := ep.GetProviderSpecificProperty(providerSpecificEfficientipSmart)
+		view, _ := ep.GetProviderSpecificProperty(providerSpecificEfficientipView)
+		_, drift := ep.GetProviderSpecificProperty(providerSpecificEfficientipDrift)
+		if ep.DNSName != e.name || smart != e.smart || view != e.view || drift != e.drift {
+			t.Errorf("endpoint %d: expected %+v, got %s smart=%q view=%q drift=%t", i, e, ep.DNSName, smart, view, drift)
+		}
+	}
+
+	// Drifted records are reported as held by the first default view, with all copies kept for the repair
+	if targets := endpoints[2].Targets; len(targets) != 1 || targets[0] != "2001:db8::3" {
+		t.Errorf("expected the targets of the internal view for the drifted record, got %v", targets)
+	}
+	c, found := drifted[updateKey(endpoints[2])]
+	if !found || len(drifted) != 1 {
+		t.Fatalf("expected only drift.example.com to be drifted, got %v", drifted)
+	}
+	expectedScopes := []recordScope{{smart: "dns-smart", view: "internal"}, {smart: "dns-smart", view: "external"}}
+	if !slices.Equal(c.scopes, expectedScopes) {
+		t.Errorf("expected copies in %v, got %v", expectedScopes, c.scopes)
+	}
+
+	// Listed endpoints may be cached and must keep their properties
+	if view, _ := zoneRecords[0][0].GetProviderSpecificProperty(providerSpecificEfficientipView); view != "listed" {
+		t.Errorf("expected listed endpoint to be left untouched, got view %q", view)
+	}
+}
+
+func TestMergeViewsWithoutView(t *testing.T) {
+	config := &EfficientIPConfig{DnsSmart: "dns-smart"}
+	zones := []*ZoneAuth{
+		{Name: "example.com", Smart: "dns-smart", View: "internal"},
+		{Name: "example.com", Smart: "dns-smart", View: "external"},
+	}
+	zoneRecords := [][]*endpoint.Endpoint{
+		{
+			endpoint.NewEndpointWithTTL("both.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("internal.example.com", endpoint.RecordTypeA, 300, "10.0.0.1"),
+			endpoint.NewEndpointWithTTL("drift.example.com", endpoint.RecordTypeA, 300, "192.0.2.2"),
+		},
+		{
+			endpoint.NewEndpointWithTTL("both.example.com", endpoint.RecordTypeA, 300, "192.0.2.1"),
+			endpoint.NewEndpointWithTTL("drift.example.com", endpoint.RecordTypeA, 300, "192.0.2.3"),
+		},
+	}
+
+	// Records are written to the views holding their zone, so they are reported without view
+	endpoints, drifted := mergeViews(config, nil, zones, zoneRecords)
+	if len(endpoints) != 3 {
+		t.Fatalf("expected 3 endpoints, got %v", endpoints)
+	}
+	for _, ep := range endpoints[:2] {
+		if len(ep.ProviderSpecific) != 0 {
+			t.Errorf("expected %s without properties, got %v", ep.DNSName, ep.ProviderSpecific)
+		}
+	}
+	if _, drift := endpoints[2].GetProviderSpecificProperty(providerSpecificEfficientipDrift); !drift || len(drifted) != 1 {
+		t.Errorf("expected drift.example.com to differ between the views, got %v", endpoints[2].ProviderSpecific)
+	}
+}
+
+func TestAdjustScope(t *testing.T) {
+	p := &Provider{config: &EfficientIPConfig{
+		DnsSmart:      "dns-smart",
+		DnsViews:      []string{"internal", "external"},
+		AllowedViews:  []string{"dmz"},
+		AllowedSmarts: []string{"other-smart"},
+	}}
+
+	testCases := []struct {
+		name          string
+		view          string
+		smart         string
+		expectedView  string
+		expectedSmart string
+		err           bool
+	}{
+		{name: "default view", view: "internal", expectedView: "internal"},
+		{name: "allowed view", view: " dmz ", expectedView: "dmz"},
+		{name: "unknown view", view: "public", err: true},
+		{name: "default smart", smart: "dns-smart"},
+		{name: "allowed smart", smart: "other-smart", expectedSmart: "other-smart"},
+		{name: "unknown smart", smart: "rogue-smart", err: true},
+	}
+
+	for _, tc := range testCases {
+		ep := endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")
+		if tc.view != "" {
+			ep.WithProviderSpecific(providerSpecificEfficientipView, tc.view)
+		}
+		if tc.smart != "" {
+			ep.WithProviderSpecific(providerSpecificEfficientipSmart, tc.smart)
+		}
+
+		err := p.adjustScope(ep)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		view, _ := ep.GetProviderSpecificProperty(providerSpecificEfficientipView)
+		smart, foundSmart := ep.GetProviderSpecificProperty(providerSpecificEfficientipSmart)
+		if view != tc.expectedView || smart != tc.expectedSmart || (tc.expectedSmart == "" && foundSmart) {
+			t.Errorf("%s: expected view %q and smart %q, got %q and %q", tc.name, tc.expectedView, tc.expectedSmart, view, smart)
+		}
+	}
+
+	// A single default view is implied and dropped
+	p.config.DnsViews = []string{"internal"}
+	ep := endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1").WithProviderSpecific(providerSpecificEfficientipView, "internal")
+	if err := p.adjustScope(ep); err != nil || len(ep.ProviderSpecific) != 0 {
+		t.Errorf("expected the default view to be dropped, got %v (err=%v)", ep.ProviderSpecific, err)
+	}
+}
+
+func TestAdjustEndpointsRejectsScope(t *testing.T) {
+	p := &Provider{config: &EfficientIPConfig{DnsSmart: "dns-smart", DnsViews: []string{"internal", "external"}, DefaultTTL: 300}}
+
+	endpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("app.example.com", endpoint.RecordTypeA, "192.0.2.2").WithProviderSpecific(providerSpecificEfficientipView, "public"),
+	}
+	// Dropping the endpoint would make external-dns delete its records
+	adjusted, err := p.AdjustEndpoints(endpoints)
+	if err == nil || !strings.Contains(err.Error(), "app.example.com") {
+		t.Errorf("expected an error naming app.example.com, got %v (%v)", err, adjusted)
+	}
+
+	setProviderSpecific(endpoints[1], providerSpecificEfficientipView, "internal")
+	if adjusted, err := p.AdjustEndpoints(endpoints); err != nil || len(adjusted) != 2 {
+		t.Errorf("expected both endpoints to be kept, got %v (%v)", adjusted, err)
+	}
+}
+
+func TestRecordScopes(t *testing.T) {
+	api := &EfficientIPAPI{dnsName: "dns-smart", dnsViews: []string{"internal", "external"}}
+
+	ep := endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "192.0.2.1")
+	expected := []recordScope{{smart: "dns-smart", view: "internal"}, {smart: "dns-smart", view: "external"}}
+	if scopes := api.recordScopes(ep); !slices.Equal(scopes, expected) {
+		t.Errorf("expected default scopes %v, got %v", expected, scopes)
+	}
+
+	ep.WithProviderSpecific(providerSpecificEfficientipView, "dmz").WithProviderSpecific(providerSpecificEfficientipSmart, "other-smart")
+	expected = []recordScope{{smart: "other-smart", view: "dmz"}}
+	if scopes := api.recordScopes(ep); !slices.Equal(scopes, expected) {
+		t.Errorf("expected selected scope %v, got %v", expected, scopes)
+	}
+}
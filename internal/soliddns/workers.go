@@ -0,0 +1,69 @@
+package soliddns
+
+import (
+	"context"
+	"sync"
+)
+
+// runWorkers calls fn for the indexes 0..count-1 on at most workers goroutines.
+// After the first error no further indexes are handed out. If cancelOnError is set,
+// the context passed to calls still running is cancelled as well; otherwise they complete.
+// Parameters:
+//   - ctx: Caller context
+//   - count: Number of jobs
+//   - workers: Maximum number of concurrent jobs (values < 1 run jobs one at a time)
+//   - cancelOnError: Whether the first error cancels running jobs
+//   - fn: Job function
+//
+// Returns:
+//   - The first job error, or the context error if the caller gave up before all jobs were handed out
+func runWorkers(ctx context.Context, count, workers int, cancelOnError bool, fn func(ctx context.Context, i int) error) error {
+	feedCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	workCtx := ctx
+	if cancelOnError {
+		workCtx = feedCtx
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	jobs := make(chan int)
+	for range min(max(workers, 1), count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(workCtx, i); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						stop()
+					})
+				}
+			}
+		}()
+	}
+
+	fed := 0
+feed:
+	for ; fed < count && feedCtx.Err() == nil; fed++ {
+		select {
+		case jobs <- fed:
+		case <-feedCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if fed < count {
+		return ctx.Err()
+	}
+	return nil
+}